@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"pf-launcher/internal/arweave"
+	"pf-launcher/internal/imageprep"
+	"pf-launcher/internal/services"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func newArweaveClient() *arweave.Client {
+	key, err := solana.PrivateKeyFromBase58(os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatalf("PRIVATE_KEY is not usable: %v", err)
+	}
+	return arweave.NewClient(os.Getenv("IRYS_URL"), os.Getenv("ARWEAVE_GATEWAY"), key)
+}
+
+// runArweaveFund shows the Irys balance and upload price, and tops the
+// balance up with -sol.
+func runArweaveFund(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("arweave-fund", flag.ExitOnError)
+	sol := fs.Float64("sol", 0, "SOL to add to the Irys balance")
+	size := fs.Int("bytes", imageprep.DefaultMaxBytes, "upload size to quote a price for")
+	register := fs.String("tx", "", "signature of an earlier funding transfer Irys has not credited")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	client := newArweaveClient()
+
+	balance, err := client.Balance(ctx)
+	if err != nil {
+		log.Fatalf("Failed to get Irys balance: %v", err)
+	}
+	price, err := client.Price(ctx, *size)
+	if err != nil {
+		log.Fatalf("Failed to get Irys price: %v", err)
+	}
+	log.Printf("Irys balance %d lamports, uploading %d bytes costs %d lamports", balance, *size, price)
+
+	if *register != "" {
+		sig, err := solana.SignatureFromBase58(*register)
+		if err != nil {
+			log.Fatalf("Invalid -tx: %v", err)
+		}
+		if err := client.RegisterFunding(ctx, sig); err != nil {
+			log.Fatalf("Failed to register funding: %v", err)
+		}
+		log.Printf("Irys balance funded by %s", sig)
+	}
+	if *sol <= 0 {
+		return
+	}
+
+	address, err := client.FundingAddress(ctx)
+	if err != nil {
+		log.Fatalf("Failed to get Irys funding address: %v", err)
+	}
+	rpcClient, err := services.NewRPCClient(os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to create RPC client: %v", err)
+	}
+	defer rpcClient.Close()
+
+	lamports := uint64(*sol * 1e9)
+	sig, err := rpcClient.Transfer(ctx, address, lamports)
+	if err != nil {
+		log.Fatalf("Failed to send %d lamports to %s: %v", lamports, address, err)
+	}
+	log.Printf("Sent %d lamports to %s - signature: %s", lamports, address, sig)
+	if err := client.RegisterFunding(ctx, sig); err != nil {
+		log.Fatalf("Transfer %s confirmed but Irys did not credit it yet, retry with -tx %s: %v", sig, sig, err)
+	}
+	log.Printf("Irys balance funded")
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pf-launcher/internal/batch"
+	"pf-launcher/internal/pinata"
+	"pf-launcher/internal/services"
+	"pf-launcher/internal/storage"
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// runLaunchBatch launches every token in a manifest. Progress is saved after
+// each step, so re-running the same command resumes without relaunching
+// finished items.
+func runLaunchBatch(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("launch-batch", flag.ExitOnError)
+	manifest := fs.String("manifest", "", "CSV or JSON manifest of tokens")
+	state := fs.String("state", "", "progress file (defaults to the manifest path with .state.json)")
+	report := fs.String("report", "", "summary report (defaults to the manifest path with .report.csv)")
+	concurrency := fs.Int("concurrency", 2, "items uploaded and launched at once")
+	retryFailed := fs.Bool("retry-failed", false, "retry items that failed in an earlier run")
+	timeout := fs.Duration("timeout", rpcTimeout, "time allowed to build and send each launch")
+	gf := registerGatewayFlags(fs)
+	fs.Parse(args)
+
+	if *manifest == "" {
+		log.Fatalf("Pass -manifest")
+	}
+	base := strings.TrimSuffix(*manifest, ".json")
+	base = strings.TrimSuffix(base, ".csv")
+	if *state == "" {
+		*state = base + ".state.json"
+	}
+	if *report == "" {
+		*report = base + ".report.csv"
+	}
+
+	items, err := batch.LoadManifest(*manifest)
+	if err != nil {
+		log.Fatalf("Failed to load manifest: %v", err)
+	}
+	b, err := batch.Open(*manifest, items, *state)
+	if err != nil {
+		log.Fatalf("Failed to open batch: %v", err)
+	}
+	invalid := 0
+	for i, entry := range b.Entries {
+		if err := entryFlags(entry).validate(); err != nil {
+			log.Printf("Item %d (%s): %v", i+1, entry.Symbol, err)
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		log.Fatalf("%d manifest items are invalid, nothing was uploaded", invalid)
+	}
+
+	rpcClient, err := services.NewRPCClient(os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to create RPC client: %v", err)
+	}
+	defer rpcClient.Close()
+	if err := rpcClient.Prewarm(ctx); err != nil {
+		log.Fatalf("Failed to prewarm: %v", err)
+	}
+	store := newStorage()
+	labels := newLabeler(ctx)
+
+	b.Run(ctx, *concurrency, func(ctx context.Context, entry *batch.Entry) {
+		if entry.Status == batch.StatusFailed && !*retryFailed {
+			return
+		}
+		if err := launchEntry(ctx, rpcClient, store, labels, gf, b, entry, *timeout); err != nil {
+			log.Printf("%s failed: %v", entry.Symbol, err)
+			update(b, entry, func(e *batch.Entry) {
+				e.Status, e.Error = batch.StatusFailed, err.Error()
+			})
+		}
+	})
+
+	if err := b.WriteReport(*report); err != nil {
+		log.Printf("Failed to write report: %v", err)
+	}
+	counts := b.Counts()
+	log.Printf("Batch done - launched %d, failed %d, pending %d of %d, report in %s",
+		counts[batch.StatusLaunched], counts[batch.StatusFailed],
+		len(b.Entries)-counts[batch.StatusLaunched]-counts[batch.StatusFailed], len(b.Entries), *report)
+	if ctx.Err() != nil {
+		log.Printf("Interrupted, run the same command again to resume")
+	}
+}
+
+func launchEntry(ctx context.Context, rpcClient *services.RPCClient, store storage.Storage, labels *labeler, gf *gatewayFlags, b *batch.Batch, entry *batch.Entry, timeout time.Duration) error {
+	// Uploads are labeled with the launch so pinata-prune can find them.
+	launchID := filepath.Base(b.Manifest) + ":" + entry.Symbol
+
+	// A launch interrupted after sending may have landed. Only relaunch it
+	// once its blockhash has expired without it showing up.
+	if entry.Status == batch.StatusLaunching && entry.Signature != "" {
+		sig, err := solana.SignatureFromBase58(entry.Signature)
+		if err != nil {
+			return err
+		}
+		landed, err := rpcClient.ResolveLaunch(ctx, sig, entry.LastValidBlockHeight)
+		if landed {
+			if err != nil {
+				return err
+			}
+			log.Printf("%s already landed as %s", entry.Symbol, sig)
+			labels.label(ctx, map[string]string{pinata.KeyLaunch: launchID, pinata.KeyMint: entry.Mint}, entry.MetadataURI)
+			update(b, entry, func(e *batch.Entry) { e.Status, e.Error = batch.StatusLaunched, "" })
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("%s: earlier transaction %s expired without landing, relaunching", entry.Symbol, sig)
+	}
+
+	f := entryFlags(entry)
+	if err := f.validate(); err != nil {
+		return err
+	}
+
+	estimateCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	estimate, err := rpcClient.EstimateLaunch(estimateCtx, f.buyAmount())
+	cancel()
+	if err != nil {
+		return err
+	}
+	if err := estimate.Check(); err != nil {
+		return err
+	}
+
+	if entry.MetadataURI == "" {
+		uploaded, metadataUri, err := uploadTokenMetadata(ctx, store, f)
+		if err != nil {
+			return err
+		}
+		labels.label(ctx, map[string]string{pinata.KeyLaunch: launchID}, uploaded.Image, metadataUri)
+		update(b, entry, func(e *batch.Entry) { e.Status, e.MetadataURI = batch.StatusUploaded, metadataUri })
+		log.Printf("%s metadata uploaded to %s", entry.Symbol, metadataUri)
+	}
+	if err := waitForGateways(ctx, gf, entry.MetadataURI); err != nil {
+		return err
+	}
+
+	// The create instruction only carries the name and symbol, the rest is
+	// behind the metadata URI.
+	metadata := types.Metadata{Name: f.name, Symbol: f.symbol}
+	update(b, entry, func(e *batch.Entry) {
+		e.Status, e.Signature, e.LastValidBlockHeight, e.Error = batch.StatusLaunching, "", 0, ""
+	})
+
+	launchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Keep the signature before sending so a resumed run can check whether
+	// it landed.
+	result, err := rpcClient.LaunchToken(launchCtx, metadata, entry.MetadataURI, f.buyAmount(), func(cp services.LaunchCheckpoint) {
+		if cp.Stage == services.StageSend {
+			update(b, entry, func(e *batch.Entry) {
+				e.Mint, e.Signature, e.LastValidBlockHeight = cp.Mint.String(), cp.Signature.String(), cp.LastValidBlockHeight
+			})
+		}
+	})
+	var launchErr *services.LaunchError
+	if errors.As(err, &launchErr) && !launchErr.Signature.IsZero() {
+		update(b, entry, func(e *batch.Entry) { e.Error = err.Error() })
+		log.Printf("%s: %v, its transaction %s may still land and is checked on the next run", entry.Symbol, err, launchErr.Signature)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	update(b, entry, func(e *batch.Entry) {
+		e.Status = batch.StatusLaunched
+		e.Mint, e.Signature = result.Mint.String(), result.Signature.String()
+		e.TokensReceived, e.SolSpent = result.TokensReceived, result.SolSpent
+	})
+	labels.label(ctx, map[string]string{pinata.KeyLaunch: launchID, pinata.KeyMint: result.Mint.String()}, entry.MetadataURI)
+	log.Printf("%s launched %s - signature: %s", entry.Symbol, result.Mint, result.Signature)
+	return nil
+}
+
+func entryFlags(entry *batch.Entry) *launchFlags {
+	return &launchFlags{
+		name:        entry.Name,
+		symbol:      entry.Symbol,
+		description: entry.Description,
+		twitter:     entry.Twitter,
+		telegram:    entry.Telegram,
+		website:     entry.Website,
+		image:       entry.Image,
+		buySol:      entry.BuySol,
+	}
+}
+
+func update(b *batch.Batch, entry *batch.Entry, fn func(e *batch.Entry)) {
+	if err := b.Update(entry, fn); err != nil {
+		log.Printf("Failed to save batch state: %v", err)
+	}
+}
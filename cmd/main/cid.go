@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+
+	"pf-launcher/internal/ipfs"
+)
+
+// runCID prints the IPFS URIs the image and metadata will get, without
+// uploading anything.
+func runCID(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("cid", flag.ExitOnError)
+	lf := registerLaunchFlags(fs)
+	fs.Parse(args)
+	if err := lf.validate(); err != nil {
+		log.Fatalf("Invalid token: %v", err)
+	}
+
+	imageURI := lf.image
+	if _, ok := lf.imageCID(); !ok {
+		img, _, err := prepareImage(lf)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		imageURI = "ipfs://" + ipfs.CID(img.Data)
+	}
+	metadata, err := json.Marshal(lf.metadata(imageURI))
+	if err != nil {
+		log.Fatalf("Failed to marshal metadata: %v", err)
+	}
+
+	fmt.Printf("image    %s\n", imageURI)
+	fmt.Printf("metadata ipfs://%s\n", ipfs.CID(metadata))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"pf-launcher/internal/decode"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func runDecode(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	sig := fs.String("sig", "", "transaction signature to fetch")
+	raw := fs.String("raw", "", "raw base64 transaction, decoded without fetching")
+	fs.Parse(args)
+
+	var (
+		tx   *solana.Transaction
+		meta *rpc.TransactionMeta
+		err  error
+	)
+	switch {
+	case *sig != "":
+		signature, err := solana.SignatureFromBase58(*sig)
+		if err != nil {
+			log.Fatalf("Invalid signature: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+		defer cancel()
+		tx, meta, err = watchOnlyClient("").GetTransaction(ctx, signature)
+		if err != nil {
+			log.Fatalf("Failed to fetch transaction: %v", err)
+		}
+	case *raw != "":
+		tx, err = solana.TransactionFromBase64(*raw)
+		if err != nil {
+			log.Fatalf("Invalid transaction: %v", err)
+		}
+	default:
+		log.Fatalf("Pass -sig or -raw")
+	}
+
+	decoded, err := decode.Decode(tx, meta)
+	if err != nil {
+		log.Fatalf("Failed to decode transaction: %v", err)
+	}
+	fmt.Println(decoded)
+}
+
+func runFill(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("fill", flag.ExitOnError)
+	sig := fs.String("sig", "", "transaction signature")
+	mint := fs.String("mint", "", "token mint")
+	owner := fs.String("owner", "", "trader public key (defaults to PRIVATE_KEY)")
+	fs.Parse(args)
+
+	signature, err := solana.SignatureFromBase58(*sig)
+	if err != nil {
+		log.Fatalf("Invalid signature: %v", err)
+	}
+	mintKey, err := solana.PublicKeyFromBase58(*mint)
+	if err != nil {
+		log.Fatalf("Invalid mint: %v", err)
+	}
+	requireOwner(*owner)
+
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	f, err := watchOnlyClient(*owner).GetFill(ctx, signature, mintKey)
+	if err != nil {
+		log.Fatalf("Failed to read fill: %v", err)
+	}
+	fmt.Println(f)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"pf-launcher/internal/gateway"
+)
+
+type gatewayFlags struct {
+	required int
+	wait     time.Duration
+}
+
+func registerGatewayFlags(fs *flag.FlagSet) *gatewayFlags {
+	g := &gatewayFlags{}
+	fs.IntVar(&g.required, "gateways", 1, "IPFS gateways from IPFS_GATEWAYS that must serve the metadata and image before launching, 0 to skip the check")
+	fs.DurationVar(&g.wait, "gateway-wait", 2*time.Minute, "time allowed for the gateways to serve the uploads")
+	return g
+}
+
+// waitForGateways holds the launch until enough public gateways serve the
+// uploaded metadata and image, so the token does not appear without them.
+func waitForGateways(ctx context.Context, g *gatewayFlags, metadataUri string) error {
+	if g.required <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, g.wait)
+	defer cancel()
+
+	start := time.Now()
+	results, err := gateway.NewVerifier(os.Getenv("IPFS_GATEWAYS"), g.required).Wait(ctx, metadataUri)
+	for _, result := range results {
+		log.Printf("Gateway %s", result)
+	}
+	if err != nil {
+		return fmt.Errorf("uploads not available after %s: %w", time.Since(start).Round(time.Second), err)
+	}
+	return nil
+}
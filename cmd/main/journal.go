@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"pf-launcher/internal/journal"
+	"pf-launcher/internal/services"
+	"pf-launcher/internal/storage"
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const defaultJournal = "launches.jsonl"
+
+// launchKey identifies a launch by its flags and image contents, or image
+// CID.
+func launchKey(f *launchFlags) (string, error) {
+	if _, ok := f.imageCID(); ok {
+		return journal.Key(f.name, f.symbol, f.description, f.twitter, f.telegram, f.website,
+			f.image, strconv.FormatUint(f.buyAmount(), 10)), nil
+	}
+	data, err := os.ReadFile(f.image)
+	if err != nil {
+		return "", err
+	}
+	image := sha256.Sum256(data)
+	return journal.Key(f.name, f.symbol, f.description, f.twitter, f.telegram, f.website,
+		hex.EncodeToString(image[:]), strconv.FormatUint(f.buyAmount(), 10)), nil
+}
+
+// resumeLaunch finds the last attempt at the launch with key. A signed
+// transaction from it is settled against the chain first, so an interrupted
+// launch is never sent twice. It returns the entry to continue, or done when
+// the token is already out.
+func resumeLaunch(ctx context.Context, rpcClient *services.RPCClient, j *journal.Journal, key string, f *launchFlags, relaunch bool) (*journal.Entry, bool) {
+	prev := j.Latest(key)
+	if prev != nil && prev.Pending() {
+		log.Printf("Settling interrupted launch %s (%s)", prev.ID, prev.Signature)
+		if err := settle(ctx, rpcClient, j, prev); err != nil {
+			log.Fatalf("Failed to settle launch %s: %v", prev.ID, err)
+		}
+		prev = j.Latest(key)
+	}
+
+	if prev != nil && prev.Stage == journal.StageConfirmed && !relaunch {
+		log.Printf("Already launched %s as %s - signature: %s", prev.Symbol, prev.Mint, prev.Signature)
+		log.Printf("Pass -relaunch to launch it again")
+		return nil, true
+	}
+
+	// Continue an unfinished attempt, keeping whatever it uploaded.
+	if prev != nil && prev.Stage != journal.StageConfirmed {
+		if prev.MetadataURI != "" {
+			log.Printf("Resuming launch %s with metadata %s", prev.ID, prev.MetadataURI)
+		}
+		return prev, false
+	}
+
+	id, err := j.Start(key, f.name, f.symbol)
+	if err != nil {
+		log.Fatalf("Failed to write journal: %v", err)
+	}
+	entry := &journal.Entry{Record: journal.Record{ID: id, Key: key}}
+	if prev != nil {
+		entry.ImageCID, entry.MetadataURI = prev.ImageCID, prev.MetadataURI
+	}
+	return entry, false
+}
+
+// settle records whether the pending launch e landed.
+func settle(ctx context.Context, rpcClient *services.RPCClient, j *journal.Journal, e *journal.Entry) error {
+	sig, err := solana.SignatureFromBase58(e.Signature)
+	if err != nil {
+		return err
+	}
+	landed, err := rpcClient.ResolveLaunch(ctx, sig, e.LastValidBlockHeight)
+	switch {
+	case landed && err == nil:
+		log.Printf("Launch %s landed as %s", e.ID, e.Mint)
+		return j.Record(journal.Record{ID: e.ID, Stage: journal.StageConfirmed})
+	case landed:
+		log.Printf("Launch %s landed and failed: %v", e.ID, err)
+		return j.Record(journal.Record{ID: e.ID, Stage: journal.StageFailed, Error: err.Error()})
+	case err == nil:
+		log.Printf("Launch %s expired without landing", e.ID)
+		return j.Record(journal.Record{ID: e.ID, Stage: journal.StageFailed, Error: "expired without landing"})
+	}
+	return err
+}
+
+// uploadJournaled uploads whatever entry does not have yet, recording each
+// upload so a retry can reuse it.
+func uploadJournaled(ctx context.Context, j *journal.Journal, entry *journal.Entry, f *launchFlags) (types.Metadata, string) {
+	store := newStorage()
+
+	fail := func(err error) {
+		if ctx.Err() != nil {
+			log.Fatalf("Interrupted while uploading (%v), nothing was launched", err)
+		}
+		log.Fatalf("Failed to upload metadata: %v", err)
+	}
+
+	imageCID := entry.ImageCID
+	if imageCID == "" {
+		var err error
+		imageCID, err = uploadImage(ctx, store, f)
+		if err != nil {
+			fail(err)
+		}
+		record(j, journal.Record{ID: entry.ID, Stage: journal.StageImageUploaded, ImageCID: imageCID})
+	}
+
+	metadata := f.metadata(store.URI(imageCID))
+	if entry.MetadataURI != "" {
+		record(j, journal.Record{ID: entry.ID, Stage: journal.StageMetadataUploaded, ImageCID: imageCID, MetadataURI: entry.MetadataURI})
+		return metadata, entry.MetadataURI
+	}
+	metadataCID, err := storage.PutJSON(ctx, store, "metadata.json", metadata)
+	if err != nil {
+		fail(fmt.Errorf("failed to upload metadata (image %s): %w", metadata.Image, err))
+	}
+	metadataUri := store.URI(metadataCID)
+	record(j, journal.Record{ID: entry.ID, Stage: journal.StageMetadataUploaded, MetadataURI: metadataUri})
+	return metadata, metadataUri
+}
+
+func record(j *journal.Journal, r journal.Record) {
+	if err := j.Record(r); err != nil {
+		log.Printf("Failed to write journal: %v", err)
+	}
+}
+
+func runJournal(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("journal", flag.ExitOnError)
+	path := fs.String("journal", defaultJournal, "launch journal")
+	all := fs.Bool("all", false, "include confirmed and failed launches")
+	reconcile := fs.Bool("reconcile", false, "settle launches that were sent but not confirmed against the chain")
+	fs.Parse(args)
+
+	j, err := journal.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open journal: %v", err)
+	}
+	defer j.Close()
+
+	if *reconcile {
+		rpcClient, err := services.NewRPCClient(os.Getenv("PRIVATE_KEY"))
+		if err != nil {
+			log.Fatalf("Failed to create RPC client: %v", err)
+		}
+		defer rpcClient.Close()
+		for _, e := range j.Entries() {
+			if !e.Pending() {
+				continue
+			}
+			if err := settle(ctx, rpcClient, j, e); err != nil {
+				log.Printf("Failed to settle launch %s: %v", e.ID, err)
+			}
+		}
+	}
+
+	for _, e := range j.Entries() {
+		if *all || (e.Stage != journal.StageConfirmed && e.Stage != journal.StageFailed) {
+			fmt.Println(e)
+		}
+	}
+}
@@ -1,12 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"pf-launcher/internal/imageprep"
+	"pf-launcher/internal/journal"
+	"pf-launcher/internal/kubo"
 	"pf-launcher/internal/pinata"
 	"pf-launcher/internal/services"
+	"pf-launcher/internal/storage"
 	"pf-launcher/internal/types"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -24,46 +35,326 @@ func LoadEnvironment() {
 	}
 }
 
+var commands = map[string]func(ctx context.Context, args []string){
+	"launch":        runLaunch,
+	"export-launch": runExportLaunch,
+	"export-trade":  runExportTrade,
+	"sign":          runSign,
+	"submit":        runSubmit,
+	"decode":        runDecode,
+	"fill":          runFill,
+	"launch-batch":  runLaunchBatch,
+	"journal":       runJournal,
+	"arweave-fund":  runArweaveFund,
+	"cid":           runCID,
+
+	"schedule":         runSchedule,
+	"schedule-list":    runScheduleList,
+	"schedule-cancel":  runScheduleCancel,
+	"schedule-inspect": runScheduleInspect,
+	"schedule-run":     runScheduleRun,
+
+	"pinata-files":  runPinataFiles,
+	"pinata-file":   runPinataFile,
+	"pinata-delete": runPinataDelete,
+	"pinata-groups": runPinataGroups,
+	"pinata-prune":  runPinataPrune,
+
+	"pinata-sign-url":      runPinataSignURL,
+	"pinata-upload-signed": runPinataUploadSigned,
+}
+
 func main() {
 	LoadEnvironment()
 
-	start := time.Now()
+	name, args := "launch", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	command, ok := commands[name]
+	if !ok {
+		log.Fatalf("Unknown command %q", name)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	command(ctx, args)
+}
+
+// rpcTimeout bounds the on-chain part of a command.
+const rpcTimeout = 30 * time.Second
+
+type launchFlags struct {
+	name        string
+	symbol      string
+	description string
+	twitter     string
+	telegram    string
+	website     string
+	image       string
+	imageSize   int
+	imageBytes  int
+	buySol      float64
+}
+
+func registerLaunchFlags(fs *flag.FlagSet) *launchFlags {
+	f := &launchFlags{}
+	fs.StringVar(&f.name, "name", "Test Token", "token name")
+	fs.StringVar(&f.symbol, "symbol", "TEST", "token symbol")
+	fs.StringVar(&f.description, "description", "Test Description", "token description")
+	fs.StringVar(&f.twitter, "twitter", "https://x.com/test", "twitter link")
+	fs.StringVar(&f.telegram, "telegram", "https://t.me/test", "telegram link")
+	fs.StringVar(&f.website, "website", "https://test.com", "website link")
+	fs.StringVar(&f.image, "image", "tweet_surge_io.jpg", "path to the token image, or ipfs://<cid> of one uploaded already")
+	fs.IntVar(&f.imageSize, "image-size", imageprep.DefaultMaxSize, "largest width and height of the uploaded image, in pixels")
+	fs.IntVar(&f.imageBytes, "image-bytes", imageprep.DefaultMaxBytes, "largest size of the uploaded image, in bytes")
+	fs.Float64Var(&f.buySol, "buy", 0.01, "initial buy in SOL")
+	return f
+}
+
+func (f *launchFlags) buyAmount() uint64 {
+	return uint64(f.buySol * 1e9)
+}
+
+// validate checks the token fields before anything is uploaded, normalizing
+// the social links in place.
+func (f *launchFlags) validate() error {
+	metadata := f.metadata("")
+	if err := metadata.Validate(); err != nil {
+		return err
+	}
+	if cid, ok := f.imageCID(); ok {
+		if cid == "" {
+			return fmt.Errorf("image: no CID after ipfs://")
+		}
+	} else if err := imageprep.Check(f.image); err != nil {
+		return fmt.Errorf("image: %w", err)
+	}
+	f.name, f.symbol = metadata.Name, metadata.Symbol
+	f.twitter, f.telegram, f.website = metadata.Twitter, metadata.Telegram, metadata.Website
+	return nil
+}
+
+// imageCID returns the CID of an image given as ipfs://<cid>, which is used
+// as it is instead of being prepared and uploaded.
+func (f *launchFlags) imageCID() (string, bool) {
+	return strings.CutPrefix(f.image, "ipfs://")
+}
+
+func (f *launchFlags) metadata(imageURI string) types.Metadata {
+	return types.Metadata{
+		Name:        f.name,
+		Symbol:      f.symbol,
+		Description: f.description,
+		Twitter:     f.twitter,
+		Telegram:    f.telegram,
+		Website:     f.website,
+		Image:       imageURI,
+	}
+}
+
+// uploadMetadata uploads the image and metadata JSON and returns the
+// metadata with its URI.
+func uploadMetadata(ctx context.Context, f *launchFlags) (types.Metadata, string) {
+	metadata, metadataUri, err := uploadTokenMetadata(ctx, newStorage(), f)
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Fatalf("Interrupted while uploading (%v), nothing was launched", err)
+		}
+		log.Fatalf("Failed to upload metadata: %v", err)
+	}
+	return metadata, metadataUri
+}
+
+func uploadTokenMetadata(ctx context.Context, store storage.Storage, f *launchFlags) (types.Metadata, string, error) {
+	imageCID, err := uploadImage(ctx, store, f)
+	if err != nil {
+		return types.Metadata{}, "", err
+	}
+
+	metadata := f.metadata(store.URI(imageCID))
+	metadataCID, err := storage.PutJSON(ctx, store, "metadata.json", metadata)
+	if err != nil {
+		return types.Metadata{}, "", fmt.Errorf("failed to upload metadata (image %s): %w", metadata.Image, err)
+	}
+
+	return metadata, store.URI(metadataCID), nil
+}
 
-	pinataClient := pinata.NewClient(os.Getenv("PINATA_JWT_SECRET"))
+// prepareImage crops, resizes and re-encodes the token image and returns it
+// with the file name to upload it as.
+func prepareImage(f *launchFlags) (*imageprep.Result, string, error) {
+	img, err := imageprep.ProcessFile(f.image, imageprep.Options{MaxSize: f.imageSize, MaxBytes: f.imageBytes})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to prepare image: %w", err)
+	}
+	log.Printf("Image %s: %s", f.image, img)
+	return img, strings.TrimSuffix(filepath.Base(f.image), filepath.Ext(f.image)) + img.Ext(), nil
+}
+
+func uploadImage(ctx context.Context, store storage.Storage, f *launchFlags) (string, error) {
+	if cid, ok := f.imageCID(); ok {
+		if store.URI(cid) != f.image {
+			return "", fmt.Errorf("image %s is on IPFS but the storage links %s", f.image, store.URI(cid))
+		}
+		log.Printf("Image %s: uploaded already", f.image)
+		return cid, nil
+	}
+	img, name, err := prepareImage(f)
+	if err != nil {
+		return "", err
+	}
+	imageCID, err := storage.PutBytes(ctx, store, name, img.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image file: %w", err)
+	}
+	return imageCID, nil
+}
+
+// newStorage returns the backend named by STORAGE, Pinata by default.
+func newStorage() storage.Storage {
+	switch backend := os.Getenv("STORAGE"); backend {
+	case "", "pinata":
+		return newPinataClient()
+	case "kubo":
+		return kubo.NewClient(os.Getenv("KUBO_API"), os.Getenv("KUBO_API_AUTH"))
+	case "arweave":
+		return newArweaveClient()
+	default:
+		log.Fatalf("Unknown STORAGE %q, use pinata, kubo or arweave", backend)
+		return nil
+	}
+}
+
+func runLaunch(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("launch", flag.ExitOnError)
+	lf := registerLaunchFlags(fs)
+	timeout := fs.Duration("timeout", rpcTimeout, "time allowed to build and send the launch transaction")
+	prewarm := fs.Bool("prewarm", false, "keep a blockhash and the global account cached while uploading, so the launch signs as soon as metadata is ready")
+	journalPath := fs.String("journal", defaultJournal, "launch journal, used to recover an interrupted launch")
+	relaunch := fs.Bool("relaunch", false, "launch again even if the journal shows this token already launched")
+	gf := registerGatewayFlags(fs)
+	fs.Parse(args)
+	if err := lf.validate(); err != nil {
+		log.Fatalf("Invalid token: %v", err)
+	}
+
+	start := time.Now()
 
 	rpcClient, err := services.NewRPCClient(os.Getenv("PRIVATE_KEY"))
 	if err != nil {
 		log.Fatalf("Failed to create RPC client: %v", err)
 	}
+	defer rpcClient.Close()
+
+	if *prewarm {
+		if err := rpcClient.Prewarm(ctx); err != nil {
+			log.Fatalf("Failed to prewarm: %v", err)
+		}
+	}
 
-	imageHash, err := pinataClient.UploadFile("tweet_surge_io.jpg")
+	j, err := journal.Open(*journalPath)
+	if err != nil {
+		log.Fatalf("Failed to open journal: %v", err)
+	}
+	defer j.Close()
+	key, err := launchKey(lf)
 	if err != nil {
-		log.Fatalf("Failed to upload image file: %v", err)
+		log.Fatalf("Failed to read image: %v", err)
+	}
+	entry, done := resumeLaunch(ctx, rpcClient, j, key, lf, *relaunch)
+	if done {
+		return
 	}
 
-	buyAmount := uint64(0.01 * 1e9)
-	metadata := types.Metadata{
-		Name:        "Test Token",
-		Symbol:      "TEST",
-		Description: "Test Description",
-		Twitter:     "https://x.com/test",
-		Telegram:    "https://t.me/test",
-		Website:     "https://test.com",
-		Image:       fmt.Sprintf("ipfs://%s", imageHash),
+	checkLaunchCost(ctx, rpcClient, lf)
+	uploadStart := time.Now()
+	metadata, metadataUri := uploadJournaled(ctx, j, entry, lf)
+	upload := time.Since(uploadStart)
+	labels := newLabeler(ctx)
+	labels.label(ctx, map[string]string{pinata.KeyLaunch: entry.ID}, metadata.Image, metadataUri)
+	if err := waitForGateways(ctx, gf, metadataUri); err != nil {
+		log.Fatalf("Not launching: %v", err)
 	}
 
-	metadataHash, err := pinataClient.UploadJSON(metadata)
+	launchCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	result, err := rpcClient.LaunchToken(launchCtx, metadata, metadataUri, lf.buyAmount(), func(cp services.LaunchCheckpoint) {
+		switch cp.Stage {
+		case services.StageSend:
+			record(j, journal.Record{
+				ID: entry.ID, Stage: journal.StageSigned, Mint: cp.Mint.String(),
+				Signature: cp.Signature.String(), LastValidBlockHeight: cp.LastValidBlockHeight,
+			})
+		case services.StageConfirm:
+			record(j, journal.Record{ID: entry.ID, Stage: journal.StageSent})
+		}
+	})
 	if err != nil {
-		log.Fatalf("Failed to upload metadata: %v", err)
+		// A signed launch stays pending in the journal and is settled on the
+		// next run, anything earlier never reached the cluster.
+		var launchErr *services.LaunchError
+		if !errors.As(err, &launchErr) || launchErr.Signature.IsZero() {
+			record(j, journal.Record{ID: entry.ID, Stage: journal.StageFailed, Error: err.Error()})
+		}
+		reportLaunchError(ctx, metadataUri, err)
 	}
+	record(j, journal.Record{ID: entry.ID, Stage: journal.StageConfirmed})
+	labels.label(ctx, map[string]string{pinata.KeyMint: result.Mint.String()}, metadata.Image, metadataUri)
 
-	metadataUri := fmt.Sprintf("ipfs://%s", metadataHash)
+	log.Printf("Launched %s in slot %d - signature: %s", result.Mint, result.Slot, result.Signature)
+	log.Printf("Bonding curve: %s, token account: %s", result.BondingCurve, result.AssociatedTokenAccount)
+	if result.Fill != nil {
+		log.Printf("Received %d tokens (quoted %d) for %d lamports, fee %d", result.TokensReceived, result.Quote.Tokens, result.SolSpent, result.Fee)
+		log.Printf("Curve %d, trading fee %d, priority fee %d, rent %d lamports - %s", result.Fill.CurveSol, result.Fill.TradingFee, result.Fill.PriorityFee, result.Fill.Rent, result.Deviation)
+	}
+	result.Timings.Upload = upload
 
-	err = rpcClient.LaunchToken(metadata, metadataUri, buyAmount)
+	for _, stats := range rpcClient.EndpointStats() {
+		log.Printf("RPC %s - healthy: %t, latency: %s, error rate: %.2f, slot: %d", stats.URL, stats.Healthy, stats.Latency, stats.ErrorRate, stats.Slot)
+	}
+	for url, classes := range rpcClient.RateLimitStats() {
+		for class, stats := range classes {
+			if stats.Throttled > 0 {
+				log.Printf("RPC %s - %s calls throttled: %d, queued: %d", url, class, stats.Throttled, stats.Waiting)
+			}
+		}
+	}
+	log.Printf("Launch took %s - %s", time.Since(start), result.Timings)
+}
+
+// checkLaunchCost prints what the launch will cost and stops before anything
+// is uploaded when the wallet cannot pay for it.
+func checkLaunchCost(ctx context.Context, rpcClient *services.RPCClient, f *launchFlags) {
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	estimate, err := rpcClient.EstimateLaunch(ctx, f.buyAmount())
 	if err != nil {
+		log.Fatalf("Failed to estimate launch cost: %v", err)
+	}
+	log.Printf("Launch cost:\n%s", estimate)
+	if err := estimate.Check(); err != nil {
+		log.Fatalf("Refusing to launch: %v", err)
+	}
+}
+
+// reportLaunchError explains how far a failed or interrupted launch got, since
+// a transaction that was already signed may still land.
+func reportLaunchError(ctx context.Context, metadataUri string, err error) {
+	var launchErr *services.LaunchError
+	if !errors.As(err, &launchErr) {
 		log.Fatalf("Failed to launch token: %v", err)
 	}
 
-	elapsed := time.Since(start)
-	log.Printf("Launch took %s", elapsed)
+	if ctx.Err() != nil {
+		log.Printf("Interrupted during %s, metadata was uploaded to %s", launchErr.Stage, metadataUri)
+	}
+	if !launchErr.Mint.IsZero() {
+		log.Printf("Mint: %s", launchErr.Mint)
+	}
+	if !launchErr.Signature.IsZero() {
+		log.Printf("Transaction %s may still land, run the same command again to settle it", launchErr.Signature)
+	}
+	log.Fatalf("Failed to launch token: %v", err)
 }
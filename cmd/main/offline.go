@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"pf-launcher/internal/inspect"
+	"pf-launcher/internal/offline"
+	"pf-launcher/internal/services"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// watchOnlyClient returns an RPC client for owner, falling back to the public
+// key of PRIVATE_KEY when owner is empty. Pass "" for read-only use when
+// PRIVATE_KEY is not set.
+func watchOnlyClient(owner string) *services.RPCClient {
+	if owner == "" && os.Getenv("PRIVATE_KEY") != "" {
+		key, err := solana.PrivateKeyFromBase58(os.Getenv("PRIVATE_KEY"))
+		if err != nil {
+			log.Fatalf("PRIVATE_KEY is not usable: %v", err)
+		}
+		owner = key.PublicKey().String()
+	}
+
+	rpcClient, err := services.NewWatchOnlyRPCClient(owner)
+	if err != nil {
+		log.Fatalf("Failed to create RPC client: %v", err)
+	}
+	return rpcClient
+}
+
+func requireOwner(owner string) {
+	if owner == "" && os.Getenv("PRIVATE_KEY") == "" {
+		log.Fatalf("Pass -owner or set PRIVATE_KEY")
+	}
+}
+
+func parseNonceAccount(nonce string) solana.PublicKey {
+	if nonce == "" {
+		return solana.PublicKey{}
+	}
+	key, err := solana.PublicKeyFromBase58(nonce)
+	if err != nil {
+		log.Fatalf("Invalid nonce account: %v", err)
+	}
+	return key
+}
+
+func saveEnvelope(path string, env *offline.Envelope) {
+	if err := offline.Save(path, env); err != nil {
+		log.Fatalf("Failed to save transaction: %v", err)
+	}
+	log.Printf("Unsigned %s transaction written to %s (mint %s, signers %v)", env.Kind, path, env.Mint, env.Signers)
+}
+
+func runExportLaunch(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("export-launch", flag.ExitOnError)
+	lf := registerLaunchFlags(fs)
+	owner := fs.String("owner", "", "public key of the wallet that will sign (defaults to PRIVATE_KEY)")
+	nonce := fs.String("nonce-account", "", "durable nonce account to use instead of a recent blockhash")
+	out := fs.String("out", "launch.unsigned.json", "output file")
+	gf := registerGatewayFlags(fs)
+	fs.Parse(args)
+	if err := lf.validate(); err != nil {
+		log.Fatalf("Invalid token: %v", err)
+	}
+
+	requireOwner(*owner)
+	rpcClient := watchOnlyClient(*owner)
+	checkLaunchCost(ctx, rpcClient, lf)
+	metadata, metadataUri := uploadMetadata(ctx, lf)
+	if err := waitForGateways(ctx, gf, metadataUri); err != nil {
+		log.Fatalf("Not exporting: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	env, err := rpcClient.BuildLaunchTransaction(ctx, metadata, metadataUri, lf.buyAmount(), parseNonceAccount(*nonce))
+	if err != nil {
+		log.Fatalf("Failed to build launch transaction: %v", err)
+	}
+	saveEnvelope(*out, env)
+}
+
+func runExportTrade(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("export-trade", flag.ExitOnError)
+	side := fs.String("side", offline.KindBuy, "buy or sell")
+	mint := fs.String("mint", "", "token mint")
+	sol := fs.Float64("sol", 0.01, "SOL to spend when buying")
+	tokens := fs.Float64("tokens", 0, "tokens to sell")
+	owner := fs.String("owner", "", "public key of the wallet that will sign (defaults to PRIVATE_KEY)")
+	nonce := fs.String("nonce-account", "", "durable nonce account to use instead of a recent blockhash")
+	out := fs.String("out", "trade.unsigned.json", "output file")
+	fs.Parse(args)
+
+	mintKey, err := solana.PublicKeyFromBase58(*mint)
+	if err != nil {
+		log.Fatalf("Invalid mint: %v", err)
+	}
+
+	amount := uint64(*sol * 1e9)
+	if *side == offline.KindSell {
+		amount = uint64(*tokens * 1e6)
+	}
+
+	requireOwner(*owner)
+	rpcClient := watchOnlyClient(*owner)
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	env, err := rpcClient.BuildTradeTransaction(ctx, *side, mintKey, amount, parseNonceAccount(*nonce))
+	if err != nil {
+		log.Fatalf("Failed to build %s transaction: %v", *side, err)
+	}
+	saveEnvelope(*out, env)
+}
+
+func runSign(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	in := fs.String("in", "launch.unsigned.json", "unsigned transaction file")
+	out := fs.String("out", "", "signed transaction file (defaults to overwriting -in)")
+	keypair := fs.String("keypair", "", "solana-keygen keypair file (defaults to PRIVATE_KEY)")
+	fs.Parse(args)
+
+	var (
+		key solana.PrivateKey
+		err error
+	)
+	if *keypair != "" {
+		key, err = solana.PrivateKeyFromSolanaKeygenFile(*keypair)
+	} else {
+		key, err = solana.PrivateKeyFromBase58(os.Getenv("PRIVATE_KEY"))
+	}
+	if err != nil {
+		log.Fatalf("Failed to load signing key: %v", err)
+	}
+
+	env, err := offline.Load(*in)
+	if err != nil {
+		log.Fatalf("Failed to load transaction: %v", err)
+	}
+
+	policy, err := inspect.PolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load signing policy: %v", err)
+	}
+	tx, err := env.Tx()
+	if err != nil {
+		log.Fatalf("Failed to decode transaction: %v", err)
+	}
+	// The envelope is not trusted, so its rent only raises the built-in one.
+	report, err := policy.Guard(tx, env.Rent.Bounded())
+	if report != nil {
+		log.Printf("Transaction summary:\n%s", report)
+	}
+	if err != nil {
+		log.Fatalf("Refusing to sign: %v", err)
+	}
+
+	signed, err := env.Sign(key)
+	if err != nil {
+		log.Fatalf("Failed to sign transaction: %v", err)
+	}
+	if signed == 0 {
+		log.Fatalf("Key %s is not a required signer of this transaction", key.PublicKey())
+	}
+
+	if *out == "" {
+		*out = *in
+	}
+	if err := offline.Save(*out, env); err != nil {
+		log.Fatalf("Failed to save transaction: %v", err)
+	}
+
+	missing, err := env.MissingSigners()
+	if err != nil {
+		log.Fatalf("Failed to check signers: %v", err)
+	}
+	log.Printf("Signed as %s, written to %s, missing signers: %v", key.PublicKey(), *out, missing)
+}
+
+func runSubmit(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	in := fs.String("in", "launch.unsigned.json", "signed transaction file")
+	fs.Parse(args)
+
+	env, err := offline.Load(*in)
+	if err != nil {
+		log.Fatalf("Failed to load transaction: %v", err)
+	}
+
+	missing, err := env.MissingSigners()
+	if err != nil {
+		log.Fatalf("Failed to check signers: %v", err)
+	}
+	if len(missing) > 0 {
+		log.Fatalf("Transaction is missing signatures from %v", missing)
+	}
+
+	rpcClient := watchOnlyClient(env.Signers[0])
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	sig, err := rpcClient.SubmitEnvelope(ctx, env)
+	if err != nil {
+		log.Fatalf("Failed to submit transaction: %v", err)
+	}
+	log.Printf("%s transaction sent - mint: %s, signature: %s", env.Kind, env.Mint, sig)
+
+	if _, _, err := rpcClient.ConfirmEnvelope(ctx, env); err != nil {
+		log.Fatalf("Failed to confirm transaction %s: %v", sig, err)
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"pf-launcher/internal/journal"
+	"pf-launcher/internal/pinata"
+)
+
+func newPinataClient() *pinata.PinataClient {
+	client := pinata.NewClient(os.Getenv("PINATA_JWT_SECRET"))
+	if path := os.Getenv("UPLOAD_CACHE"); path != "off" {
+		if path == "" {
+			path = "upload-cache.json"
+		}
+		cache, err := pinata.OpenCache(path)
+		if err != nil {
+			log.Fatalf("Failed to open upload cache: %v", err)
+		}
+		client.Cache = cache
+		// A cached CID is only reused once Pinata confirms it is still pinned,
+		// unless UPLOAD_CACHE_VERIFY=false trades that check for speed.
+		client.VerifyPins = os.Getenv("UPLOAD_CACHE_VERIFY") != "false"
+	}
+	return client
+}
+
+// labeler tags uploads on Pinata with the launch they belong to and its
+// mint, and files them in the PINATA_GROUP group, so `pinata-prune` can tell
+// abandoned uploads apart. It does nothing with other storage backends.
+type labeler struct {
+	client  *pinata.PinataClient
+	groupID string
+}
+
+func newLabeler(ctx context.Context) *labeler {
+	if backend := os.Getenv("STORAGE"); backend != "" && backend != "pinata" {
+		return &labeler{}
+	}
+	l := &labeler{client: newPinataClient()}
+	if name := os.Getenv("PINATA_GROUP"); name != "" {
+		group, err := l.client.EnsureGroup(ctx, name)
+		if err != nil {
+			log.Printf("Failed to find Pinata group %s, uploads are not grouped: %v", name, err)
+		} else {
+			l.groupID = group.ID
+		}
+	}
+	return l
+}
+
+// label is best effort, a failure only costs the cleanup information.
+func (l *labeler) label(ctx context.Context, keyvalues map[string]string, uris ...string) {
+	if l.client == nil {
+		return
+	}
+	for _, uri := range uris {
+		cid, ok := strings.CutPrefix(uri, "ipfs://")
+		if !ok {
+			continue
+		}
+		if err := l.client.Label(ctx, cid, l.groupID, keyvalues); err != nil {
+			log.Printf("Failed to label %s on Pinata: %v", cid, err)
+		}
+	}
+}
+
+func formatFile(f pinata.File) string {
+	line := fmt.Sprintf("%s  %s  %8d  %s  %s", f.CreatedAt, f.CID, f.Size, f.ID, f.Name)
+	if f.GroupID != "" {
+		line += "  group=" + f.GroupID
+	}
+	keys := make([]string, 0, len(f.Keyvalues))
+	for key := range f.Keyvalues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		line += fmt.Sprintf("  %s=%s", key, f.Keyvalues[key])
+	}
+	return line
+}
+
+func groupID(ctx context.Context, client *pinata.PinataClient, name string) string {
+	if name == "" {
+		return ""
+	}
+	groups, err := client.ListGroups(ctx, name)
+	if err != nil {
+		log.Fatalf("Failed to list groups: %v", err)
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return group.ID
+		}
+	}
+	log.Fatalf("No group called %s", name)
+	return ""
+}
+
+func runPinataFiles(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pinata-files", flag.ExitOnError)
+	name := fs.String("name", "", "only files whose name contains this")
+	cid := fs.String("cid", "", "only files with this CID")
+	mimeType := fs.String("mime", "", "only files of this type")
+	group := fs.String("group", "", "only files in this group")
+	launch := fs.String("launch", "", "only files of this launch")
+	mint := fs.String("mint", "", "only files of this mint")
+	limit := fs.Int("limit", 100, "most files to list, 0 for all")
+	fs.Parse(args)
+
+	client := newPinataClient()
+	filter := pinata.FileFilter{Name: *name, CID: *cid, MimeType: *mimeType, Limit: *limit, Keyvalues: map[string]string{}}
+	filter.GroupID = groupID(ctx, client, *group)
+	if *launch != "" {
+		filter.Keyvalues[pinata.KeyLaunch] = *launch
+	}
+	if *mint != "" {
+		filter.Keyvalues[pinata.KeyMint] = *mint
+	}
+	files, err := client.AllFiles(ctx, filter)
+	if err != nil {
+		log.Fatalf("Failed to list files: %v", err)
+	}
+	for _, file := range files {
+		fmt.Println(formatFile(file))
+	}
+}
+
+func runPinataFile(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pinata-file", flag.ExitOnError)
+	id := fs.String("id", "", "file id")
+	cid := fs.String("cid", "", "CID, instead of -id")
+	var set keyvalueFlags
+	fs.Var(&set, "set", "key=value to attach to the file, can be repeated")
+	group := fs.String("add-to-group", "", "group to add the file to, created if missing")
+	fs.Parse(args)
+
+	client := newPinataClient()
+	var file *pinata.File
+	var err error
+	switch {
+	case *id != "":
+		file, err = client.GetFile(ctx, *id)
+	case *cid != "":
+		file, err = client.FileByCID(ctx, *cid)
+	default:
+		log.Fatalf("Pass -id or -cid")
+	}
+	if err != nil {
+		log.Fatalf("Failed to get file: %v", err)
+	}
+
+	if len(set) > 0 || *group != "" {
+		var groupID string
+		if *group != "" {
+			g, err := client.EnsureGroup(ctx, *group)
+			if err != nil {
+				log.Fatalf("Failed to find group: %v", err)
+			}
+			groupID = g.ID
+		}
+		if err := client.Label(ctx, file.CID, groupID, set); err != nil {
+			log.Fatalf("Failed to update file: %v", err)
+		}
+		if file, err = client.GetFile(ctx, file.ID); err != nil {
+			log.Fatalf("Failed to get file: %v", err)
+		}
+	}
+	fmt.Println(formatFile(*file))
+}
+
+// keyvalueFlags collects repeated key=value flags.
+type keyvalueFlags map[string]string
+
+func (k *keyvalueFlags) String() string {
+	return fmt.Sprint(map[string]string(*k))
+}
+
+func (k *keyvalueFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value, got %q", value)
+	}
+	if *k == nil {
+		*k = keyvalueFlags{}
+	}
+	(*k)[key] = val
+	return nil
+}
+
+func runPinataDelete(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pinata-delete", flag.ExitOnError)
+	id := fs.String("id", "", "file id to delete")
+	cid := fs.String("cid", "", "CID to unpin, deleting every file with it")
+	fs.Parse(args)
+
+	client := newPinataClient()
+	switch {
+	case *id != "":
+		if err := client.DeleteFile(ctx, *id); err != nil {
+			log.Fatalf("Failed to delete %s: %v", *id, err)
+		}
+	case *cid != "":
+		if err := client.Unpin(ctx, *cid); err != nil {
+			log.Fatalf("Failed to unpin %s: %v", *cid, err)
+		}
+	default:
+		log.Fatalf("Pass -id or -cid")
+	}
+	log.Printf("Deleted")
+}
+
+func runPinataGroups(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pinata-groups", flag.ExitOnError)
+	create := fs.String("create", "", "create a group with this name")
+	del := fs.String("delete", "", "delete the group with this id, keeping its files")
+	fs.Parse(args)
+
+	client := newPinataClient()
+	switch {
+	case *create != "":
+		group, err := client.EnsureGroup(ctx, *create)
+		if err != nil {
+			log.Fatalf("Failed to create group: %v", err)
+		}
+		log.Printf("Group %s: %s", group.Name, group.ID)
+	case *del != "":
+		if err := client.DeleteGroup(ctx, *del); err != nil {
+			log.Fatalf("Failed to delete group: %v", err)
+		}
+		log.Printf("Deleted group %s", *del)
+	default:
+		groups, err := client.ListGroups(ctx, "")
+		if err != nil {
+			log.Fatalf("Failed to list groups: %v", err)
+		}
+		for _, group := range groups {
+			fmt.Printf("%s  %s  %s\n", group.CreatedAt, group.ID, group.Name)
+		}
+	}
+}
+
+// runPinataPrune unpins uploads of launches that never got a mint. A launch
+// counts as done when any of its files has one or the journal shows it
+// confirmed or still pending, and a CID is kept while any file with it is
+// still in use.
+func runPinataPrune(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pinata-prune", flag.ExitOnError)
+	olderThan := fs.Duration("older-than", 24*time.Hour, "only prune launches whose uploads are at least this old")
+	group := fs.String("group", "", "only prune files in this group")
+	apply := fs.Bool("apply", false, "unpin the files instead of only listing them")
+	journalPath := fs.String("journal", defaultJournal, "launch journal, its confirmed and pending launches are kept")
+	fs.Parse(args)
+
+	launched := map[string]bool{}
+	j, err := journal.Open(*journalPath)
+	if err != nil {
+		log.Fatalf("Failed to open journal: %v", err)
+	}
+	for _, e := range j.Entries() {
+		if e.Stage == journal.StageConfirmed || e.Pending() {
+			launched[e.ID] = true
+		}
+	}
+	j.Close()
+
+	client := newPinataClient()
+	files, err := client.AllFiles(ctx, pinata.FileFilter{GroupID: groupID(ctx, client, *group)})
+	if err != nil {
+		log.Fatalf("Failed to list files: %v", err)
+	}
+
+	for _, file := range files {
+		if launch := file.Keyvalues[pinata.KeyLaunch]; launch != "" && file.Keyvalues[pinata.KeyMint] != "" {
+			launched[launch] = true
+		}
+	}
+	cutoff := time.Now().Add(-*olderThan)
+	abandoned := func(file pinata.File) bool {
+		launch := file.Keyvalues[pinata.KeyLaunch]
+		if launch == "" || launched[launch] {
+			return false
+		}
+		created, err := time.Parse(time.RFC3339, file.CreatedAt)
+		return err == nil && created.Before(cutoff)
+	}
+
+	keep := map[string]bool{}
+	for _, file := range files {
+		if !abandoned(file) {
+			keep[file.CID] = true
+		}
+	}
+	var prune []string
+	seen := map[string]bool{}
+	for _, file := range files {
+		if keep[file.CID] || seen[file.CID] {
+			continue
+		}
+		seen[file.CID] = true
+		prune = append(prune, file.CID)
+		log.Printf("Abandoned: %s", formatFile(file))
+	}
+
+	if !*apply {
+		log.Printf("%d abandoned uploads, pass -apply to unpin them", len(prune))
+		return
+	}
+	failed := 0
+	for _, cid := range prune {
+		if err := client.Unpin(ctx, cid); err != nil {
+			log.Printf("Failed to unpin %s: %v", cid, err)
+			failed++
+		}
+	}
+	log.Printf("Unpinned %d of %d abandoned uploads", len(prune)-failed, len(prune))
+}
+
+// runPinataSignURL mints an upload URL for someone without the JWT, like a
+// designer delivering token art.
+func runPinataSignURL(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pinata-sign-url", flag.ExitOnError)
+	expires := fs.Duration("expires", time.Hour, "how long the URL can be used")
+	maxBytes := fs.Int64("max-bytes", 10<<20, "largest file the URL accepts")
+	mimeTypes := fs.String("mime", "image/*", "comma separated types the URL accepts, empty for any")
+	name := fs.String("name", "", "name to give the uploaded file")
+	group := fs.String("group", os.Getenv("PINATA_GROUP"), "group to add the uploaded file to, created if missing")
+	fs.Parse(args)
+
+	client := newPinataClient()
+	opts := pinata.SignOptions{Expires: *expires, MaxFileSize: *maxBytes, Name: *name}
+	for _, mimeType := range strings.Split(*mimeTypes, ",") {
+		if mimeType = strings.TrimSpace(mimeType); mimeType != "" {
+			opts.MimeTypes = append(opts.MimeTypes, mimeType)
+		}
+	}
+	if *group != "" {
+		g, err := client.EnsureGroup(ctx, *group)
+		if err != nil {
+			log.Fatalf("Failed to find group: %v", err)
+		}
+		opts.GroupID = g.ID
+	}
+
+	url, err := client.SignUploadURL(ctx, opts)
+	if err != nil {
+		log.Fatalf("Failed to sign upload URL: %v", err)
+	}
+	log.Printf("Upload URL valid until %s for one file up to %d bytes, upload with `pinata-upload-signed -url '<url>' -file <path>`", time.Now().Add(*expires).UTC().Format(time.RFC3339), *maxBytes)
+	fmt.Println(url)
+}
+
+// runPinataUploadSigned uploads a file to a URL from pinata-sign-url, no
+// PINATA_JWT_SECRET needed.
+func runPinataUploadSigned(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pinata-upload-signed", flag.ExitOnError)
+	url := fs.String("url", "", "signed upload URL")
+	file := fs.String("file", "", "file to upload")
+	fs.Parse(args)
+	if *url == "" || *file == "" {
+		log.Fatalf("Pass -url and -file")
+	}
+
+	cid, err := pinata.UploadFileSigned(ctx, *url, *file)
+	if err != nil {
+		log.Fatalf("Failed to upload: %v", err)
+	}
+	log.Printf("Uploaded %s, launch with -image ipfs://%s", *file, cid)
+	fmt.Println("ipfs://" + cid)
+}
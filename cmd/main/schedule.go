@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"pf-launcher/internal/inspect"
+	"pf-launcher/internal/pinata"
+	"pf-launcher/internal/schedule"
+	"pf-launcher/internal/services"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const scheduleDir = "schedules"
+
+func runSchedule(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	lf := registerLaunchFlags(fs)
+	at := fs.String("at", "", "UTC time to launch at, RFC 3339 (e.g. 2026-01-02T15:04:05Z)")
+	slot := fs.Uint64("slot", 0, "slot to launch at, instead of -at")
+	nonce := fs.String("nonce-account", "", "durable nonce account, to pre-sign the launch now")
+	dir := fs.String("dir", scheduleDir, "schedule directory")
+	gf := registerGatewayFlags(fs)
+	fs.Parse(args)
+	if err := lf.validate(); err != nil {
+		log.Fatalf("Invalid token: %v", err)
+	}
+
+	if (*at == "") == (*slot == 0) {
+		log.Fatalf("Pass one of -at or -slot")
+	}
+	var when time.Time
+	if *at != "" {
+		var err error
+		when, err = time.Parse(time.RFC3339Nano, *at)
+		if err != nil {
+			log.Fatalf("Invalid -at: %v", err)
+		}
+		if time.Until(when) <= 0 {
+			log.Fatalf("-at %s is in the past", when.UTC())
+		}
+	}
+
+	rpcClient, err := services.NewRPCClient(os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to create RPC client: %v", err)
+	}
+	defer rpcClient.Close()
+
+	checkLaunchCost(ctx, rpcClient, lf)
+	metadata, metadataUri := uploadMetadata(ctx, lf)
+	if err := waitForGateways(ctx, gf, metadataUri); err != nil {
+		log.Fatalf("Not scheduling: %v", err)
+	}
+
+	s, err := schedule.New(metadata, metadataUri, lf.buyAmount())
+	if err != nil {
+		log.Fatalf("Failed to create schedule: %v", err)
+	}
+	s.At, s.Slot = when.UTC(), *slot
+	newLabeler(ctx).label(ctx, map[string]string{pinata.KeyLaunch: s.ID}, metadata.Image, metadataUri)
+
+	// A durable nonce keeps the transaction valid until it fires, so it can
+	// be built and signed now.
+	if *nonce != "" {
+		buildCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+		defer cancel()
+		env, err := rpcClient.BuildLaunchTransaction(buildCtx, metadata, metadataUri, lf.buyAmount(), parseNonceAccount(*nonce))
+		if err != nil {
+			log.Fatalf("Failed to build launch transaction: %v", err)
+		}
+		key, err := solana.PrivateKeyFromBase58(os.Getenv("PRIVATE_KEY"))
+		if err != nil {
+			log.Fatalf("PRIVATE_KEY is not usable: %v", err)
+		}
+		if _, err := env.Sign(key); err != nil {
+			log.Fatalf("Failed to sign launch transaction: %v", err)
+		}
+		s.Envelope, s.Mint = env, env.Mint
+	}
+
+	if err := schedule.NewStore(*dir).Save(s); err != nil {
+		log.Fatalf("Failed to save schedule: %v", err)
+	}
+	log.Printf("Scheduled %s for %s, start `schedule-run` to fire it", s.ID, s.Target())
+}
+
+func runScheduleList(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("schedule-list", flag.ExitOnError)
+	dir := fs.String("dir", scheduleDir, "schedule directory")
+	all := fs.Bool("all", false, "include finished and cancelled schedules")
+	fs.Parse(args)
+
+	schedules, err := schedule.NewStore(*dir).List()
+	if err != nil {
+		log.Fatalf("Failed to list schedules: %v", err)
+	}
+	for _, s := range schedules {
+		if *all || s.State == schedule.StatePending || s.State == schedule.StateSending {
+			fmt.Println(s)
+		}
+	}
+}
+
+func runScheduleCancel(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("schedule-cancel", flag.ExitOnError)
+	dir := fs.String("dir", scheduleDir, "schedule directory")
+	id := fs.String("id", "", "schedule id")
+	fs.Parse(args)
+
+	if err := schedule.NewStore(*dir).Cancel(*id); err != nil {
+		log.Fatalf("Failed to cancel schedule: %v", err)
+	}
+	log.Printf("Cancelled %s", *id)
+}
+
+func runScheduleInspect(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("schedule-inspect", flag.ExitOnError)
+	dir := fs.String("dir", scheduleDir, "schedule directory")
+	id := fs.String("id", "", "schedule id")
+	fs.Parse(args)
+
+	s, err := schedule.NewStore(*dir).Load(*id)
+	if err != nil {
+		log.Fatalf("Failed to load schedule: %v", err)
+	}
+
+	fmt.Println(s)
+	fmt.Printf("metadata %s\n  name %q symbol %q image %s\n", s.MetadataURI, s.Metadata.Name, s.Metadata.Symbol, s.Metadata.Image)
+	fmt.Printf("initial buy %d lamports\n", s.BuyLamports)
+	if s.Envelope == nil {
+		fmt.Println("transaction is built when the schedule fires")
+		return
+	}
+
+	fmt.Printf("nonce account %s\n", s.Envelope.NonceAccount)
+	tx, err := s.Envelope.Tx()
+	if err != nil {
+		log.Fatalf("Failed to decode transaction: %v", err)
+	}
+	report, err := inspect.Inspect(tx, s.Envelope.Rent.Bounded())
+	if err != nil {
+		log.Fatalf("Failed to inspect transaction: %v", err)
+	}
+	fmt.Println(report)
+}
+
+// runScheduleRun waits for pending schedules and fires each at its target.
+// Schedules added while it runs are picked up, and each is claimed just before
+// firing so a cancel from another process is honoured. Only one runner can use
+// a directory at a time.
+func runScheduleRun(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("schedule-run", flag.ExitOnError)
+	dir := fs.String("dir", scheduleDir, "schedule directory")
+	leadSlots := fs.Uint64("lead-slots", 1, "send this many slots before the target slot so it lands in it")
+	timeout := fs.Duration("timeout", rpcTimeout, "time allowed to send and confirm each launch")
+	fs.Parse(args)
+
+	store := schedule.NewStore(*dir)
+	unlock, err := store.LockRunner()
+	if err != nil {
+		log.Fatalf("Not running: %v", err)
+	}
+	defer unlock()
+	rpcClient, err := services.NewRPCClient(os.Getenv("PRIVATE_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to create RPC client: %v", err)
+	}
+	defer rpcClient.Close()
+	if err := rpcClient.Prewarm(ctx); err != nil {
+		log.Fatalf("Failed to prewarm: %v", err)
+	}
+
+	// A schedule left sending by an earlier run may have landed. Settle
+	// those before arming anything.
+	schedules, err := store.List()
+	if err != nil {
+		log.Fatalf("Failed to list schedules: %v", err)
+	}
+	var settling sync.WaitGroup
+	for _, s := range schedules {
+		if s.State != schedule.StateSending {
+			continue
+		}
+		settling.Add(1)
+		go func(s *schedule.Schedule) {
+			defer settling.Done()
+			trySettle(ctx, rpcClient, store, s, *timeout)
+		}(s)
+	}
+	settling.Wait()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+		// busy holds the schedules a goroutine is firing or settling.
+		busy = make(map[string]bool)
+	)
+	start := func(s *schedule.Schedule, fn func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		if busy[s.ID] {
+			return
+		}
+		busy[s.ID] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+			mu.Lock()
+			delete(busy, s.ID)
+			mu.Unlock()
+		}()
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		schedules, err := store.List()
+		if err != nil {
+			log.Printf("Failed to list schedules: %v", err)
+		}
+		for _, s := range schedules {
+			switch s.State {
+			case schedule.StatePending:
+				start(s, func() {
+					log.Printf("Armed %s for %s", s.ID, s.Target())
+					fireSchedule(ctx, rpcClient, store, s, *leadSlots, *timeout)
+				})
+			case schedule.StateSending:
+				// Left sending by a fire whose transaction may still land.
+				start(s, func() { trySettle(ctx, rpcClient, store, s, *timeout) })
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Stopping, pending schedules stay queued")
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func fireSchedule(ctx context.Context, rpcClient *services.RPCClient, store *schedule.Store, s *schedule.Schedule, leadSlots uint64, timeout time.Duration) {
+	if s.Slot > 0 {
+		target := s.Slot
+		if target > leadSlots {
+			target -= leadSlots
+		}
+		if err := rpcClient.WaitForSlot(ctx, target); err != nil {
+			return
+		}
+	} else {
+		timer := time.NewTimer(time.Until(s.At))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+
+	id := s.ID
+	s, err := store.Transition(id, schedule.StatePending, schedule.StateSending)
+	if errors.Is(err, schedule.ErrState) {
+		log.Printf("Schedule %s no longer pending, skipping", id)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to claim schedule %s: %v", id, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if s.Envelope != nil {
+		err = firePresigned(ctx, rpcClient, store, s)
+	} else {
+		err = fireLaunch(ctx, rpcClient, store, s)
+	}
+	switch {
+	case err == nil:
+		s.State = schedule.StateConfirmed
+		log.Printf("Schedule %s launched %s - signature: %s", s.ID, s.Mint, s.Signature)
+		newLabeler(ctx).label(ctx, map[string]string{pinata.KeyMint: s.Mint}, s.Metadata.Image, s.MetadataURI)
+	case s.Signature != "":
+		// A signed transaction may still land, so it stays sending until
+		// it is settled.
+		s.Error = err.Error()
+		log.Printf("Schedule %s: %v, its transaction %s may still land and is settled next", s.ID, err, s.Signature)
+	default:
+		s.State, s.Error = schedule.StateFailed, err.Error()
+		log.Printf("Schedule %s failed: %v", s.ID, err)
+	}
+	if err := store.Save(s); err != nil {
+		log.Printf("Failed to update schedule %s: %v", s.ID, err)
+	}
+}
+
+func firePresigned(ctx context.Context, rpcClient *services.RPCClient, store *schedule.Store, s *schedule.Schedule) error {
+	tx, err := s.Envelope.Tx()
+	if err != nil {
+		return err
+	}
+	s.Signature, s.LastValidBlockHeight = tx.Signatures[0].String(), s.Envelope.LastValidBlockHeight
+	if err := store.Save(s); err != nil {
+		return fmt.Errorf("failed to save signature before sending: %w", err)
+	}
+	if _, err := rpcClient.SubmitEnvelope(ctx, s.Envelope); err != nil {
+		return err
+	}
+	_, _, err = rpcClient.ConfirmEnvelope(ctx, s.Envelope)
+	return err
+}
+
+func fireLaunch(ctx context.Context, rpcClient *services.RPCClient, store *schedule.Store, s *schedule.Schedule) error {
+	// Keep the signature before sending so an interrupted run can settle it.
+	result, err := rpcClient.LaunchToken(ctx, s.Metadata, s.MetadataURI, s.BuyLamports, func(cp services.LaunchCheckpoint) {
+		if cp.Stage != services.StageSend {
+			return
+		}
+		s.Mint, s.Signature, s.LastValidBlockHeight = cp.Mint.String(), cp.Signature.String(), cp.LastValidBlockHeight
+		if err := store.Save(s); err != nil {
+			log.Printf("Failed to update schedule %s: %v", s.ID, err)
+		}
+	})
+	var launchErr *services.LaunchError
+	if errors.As(err, &launchErr) {
+		if !launchErr.Mint.IsZero() {
+			s.Mint = launchErr.Mint.String()
+		}
+		if !launchErr.Signature.IsZero() {
+			s.Signature = launchErr.Signature.String()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	s.Mint, s.Signature = result.Mint.String(), result.Signature.String()
+	log.Printf("Schedule %s - %s", s.ID, result.Timings)
+	return nil
+}
+
+func trySettle(ctx context.Context, rpcClient *services.RPCClient, store *schedule.Store, s *schedule.Schedule, timeout time.Duration) {
+	if err := settleSchedule(ctx, rpcClient, store, s, timeout); err != nil {
+		log.Printf("Failed to settle schedule %s, it stays sending: %v", s.ID, err)
+	}
+}
+
+// settleSchedule finishes a schedule left sending. One whose transaction was
+// never signed is failed, nothing reached the cluster. A pre-signed durable
+// nonce transaction that has not landed is resent as it is, which can't
+// launch twice, and fails once its nonce has moved on. Any other waits until
+// its blockhash expires, so it is never relaunched while it can land. An
+// error leaves the schedule sending to be settled again.
+func settleSchedule(ctx context.Context, rpcClient *services.RPCClient, store *schedule.Store, s *schedule.Schedule, timeout time.Duration) error {
+	if s.Signature == "" {
+		log.Printf("Schedule %s was interrupted before sending", s.ID)
+		s.State, s.Error = schedule.StateFailed, "interrupted before sending"
+		return store.Save(s)
+	}
+	sig, err := solana.SignatureFromBase58(s.Signature)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var landed bool
+	if s.Envelope != nil && s.Envelope.NonceAccount != "" {
+		landed, err = rpcClient.TransactionStatus(ctx, sig)
+		if !landed && err == nil {
+			log.Printf("Schedule %s has not landed, resending its pre-signed transaction", s.ID)
+			if _, err := rpcClient.SubmitEnvelope(ctx, s.Envelope); err != nil {
+				// Checked before sending: the nonce moved on or the
+				// transaction can't succeed, so it never lands.
+				s.State, s.Error = schedule.StateFailed, err.Error()
+				log.Printf("Schedule %s failed: %v", s.ID, err)
+				return store.Save(s)
+			}
+			_, _, err = rpcClient.ConfirmEnvelope(ctx, s.Envelope)
+			landed = err == nil
+		}
+	} else {
+		landed, err = rpcClient.ResolveLaunch(ctx, sig, s.LastValidBlockHeight)
+	}
+	switch {
+	case landed && err == nil:
+		log.Printf("Schedule %s landed as %s", s.ID, s.Mint)
+		s.State, s.Error = schedule.StateConfirmed, ""
+		newLabeler(ctx).label(ctx, map[string]string{pinata.KeyMint: s.Mint}, s.Metadata.Image, s.MetadataURI)
+	case landed:
+		log.Printf("Schedule %s landed and failed: %v", s.ID, err)
+		s.State, s.Error = schedule.StateFailed, err.Error()
+	case err == nil:
+		log.Printf("Schedule %s expired without landing", s.ID)
+		s.State, s.Error = schedule.StateFailed, "expired without landing"
+	default:
+		return err
+	}
+	return store.Save(s)
+}
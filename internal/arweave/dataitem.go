@@ -0,0 +1,150 @@
+package arweave
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// SignatureTypeSolana is the ANS-104 signature type of an ed25519 signature
+// made by a Solana key.
+const SignatureTypeSolana = 4
+
+// Tag is a name and value attached to a data item, like its Content-Type.
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// DataItem is a signed ANS-104 data item, the unit bundlers like Irys
+// accept and post to Arweave.
+type DataItem struct {
+	Signature solana.Signature
+	Owner     solana.PublicKey
+	Anchor    []byte
+	Tags      []Tag
+	Data      []byte
+}
+
+// NewDataItem signs data with key. A random anchor keeps the id unique when
+// the same content is uploaded twice.
+func NewDataItem(key solana.PrivateKey, data []byte, tags []Tag) (*DataItem, error) {
+	item := &DataItem{Owner: key.PublicKey(), Anchor: make([]byte, 32), Tags: tags, Data: data}
+	if _, err := rand.Read(item.Anchor); err != nil {
+		return nil, fmt.Errorf("failed to generate anchor: %w", err)
+	}
+
+	message, err := item.signatureData()
+	if err != nil {
+		return nil, err
+	}
+	item.Signature, err = key.Sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign data item: %w", err)
+	}
+	return item, nil
+}
+
+// ID is the Arweave id of the item, the base64url SHA-256 of its signature.
+func (d *DataItem) ID() string {
+	sum := sha256.Sum256(d.Signature[:])
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// signatureData is the deep hash the owner signs.
+func (d *DataItem) signatureData() ([]byte, error) {
+	tags, err := encodeTags(d.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return deepHash([][]byte{
+		[]byte("dataitem"),
+		[]byte("1"),
+		[]byte(strconv.Itoa(SignatureTypeSolana)),
+		d.Owner[:],
+		nil, // target
+		d.Anchor,
+		tags,
+		d.Data,
+	}), nil
+}
+
+// Bytes is the binary ANS-104 encoding of the item.
+func (d *DataItem) Bytes() ([]byte, error) {
+	tags, err := encodeTags(d.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint16(SignatureTypeSolana))
+	buf.Write(d.Signature[:])
+	buf.Write(d.Owner[:])
+	buf.WriteByte(0) // no target
+	if len(d.Anchor) > 0 {
+		buf.WriteByte(1)
+		buf.Write(d.Anchor)
+	} else {
+		buf.WriteByte(0)
+	}
+	binary.Write(&buf, binary.LittleEndian, uint64(len(d.Tags)))
+	binary.Write(&buf, binary.LittleEndian, uint64(len(tags)))
+	buf.Write(tags)
+	buf.Write(d.Data)
+	return buf.Bytes(), nil
+}
+
+// deepHash is the Arweave deep hash of a list of blobs.
+func deepHash(chunks [][]byte) []byte {
+	acc := sha512.Sum384([]byte("list" + strconv.Itoa(len(chunks))))
+	for _, chunk := range chunks {
+		pair := append(acc[:], deepHashBlob(chunk)...)
+		acc = sha512.Sum384(pair)
+	}
+	return acc[:]
+}
+
+func deepHashBlob(data []byte) []byte {
+	tag := sha512.Sum384([]byte("blob" + strconv.Itoa(len(data))))
+	body := sha512.Sum384(data)
+	sum := sha512.Sum384(append(tag[:], body[:]...))
+	return sum[:]
+}
+
+// encodeTags encodes tags as an Avro array of {name: bytes, value: bytes}
+// records, the layout ANS-104 specifies.
+func encodeTags(tags []Tag) ([]byte, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if len(tags) > 128 {
+		return nil, fmt.Errorf("data item has %d tags, at most 128 are allowed", len(tags))
+	}
+
+	var buf bytes.Buffer
+	writeLong(&buf, int64(len(tags)))
+	for _, tag := range tags {
+		if tag.Name == "" || len(tag.Name) > 1024 || len(tag.Value) > 3072 {
+			return nil, fmt.Errorf("tag %q: names must be 1-1024 bytes and values at most 3072", tag.Name)
+		}
+		writeLong(&buf, int64(len(tag.Name)))
+		buf.WriteString(tag.Name)
+		writeLong(&buf, int64(len(tag.Value)))
+		buf.WriteString(tag.Value)
+	}
+	writeLong(&buf, 0)
+	return buf.Bytes(), nil
+}
+
+// writeLong writes an Avro long, zigzag then varint encoded.
+func writeLong(buf *bytes.Buffer, n int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64((n<<1)^(n>>63)))])
+}
@@ -0,0 +1,144 @@
+package arweave
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// The vector is a data item with a fixed key and anchor, so the ed25519
+// signature and id are deterministic. It was computed by a separate ANS-104
+// implementation.
+const (
+	vectorOwner     = "79b5562e8fe654f94078b112e8a98ba7901f853ae695bed7e0e3910bad049664"
+	vectorTags      = "0418436f6e74656e742d5479706514746578742f706c61696e104170702d4e616d651670662d6c61756e6368657200"
+	vectorDeepHash  = "ef668053827d8fa4fc8a64090109b98700aa22cc4b5eddf647ed3823e8e3d7874090f9009ca785acff626a89f55dc97a"
+	vectorSignature = "d4b59044c7a1faadea8176ba70b5da10406ae4e536f3784207753c7317aa1b10bfd3047baad3894f6f56469d11fdba8355077fc07e538f6c5aa9061669ed5600"
+	vectorID        = "ot8MDsG8M8mAC7GrFvX95QSlDnDk598Z0sgrL0ugYYE"
+	vectorItem      = "0400" + vectorSignature + vectorOwner +
+		"00" + // no target
+		"01" + "abababababababababababababababababababababababababababababababab" + // anchor
+		"0200000000000000" + "2f00000000000000" + // tag count, tag bytes
+		vectorTags + "68656c6c6f20776f726c64" // "hello world"
+)
+
+func vectorKey() solana.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i + 1)
+	}
+	return solana.PrivateKey(ed25519.NewKeyFromSeed(seed))
+}
+
+func vectorDataItem(t *testing.T) *DataItem {
+	t.Helper()
+	key := vectorKey()
+	item := &DataItem{
+		Owner:  key.PublicKey(),
+		Anchor: bytes.Repeat([]byte{0xab}, 32),
+		Tags:   []Tag{{Name: "Content-Type", Value: "text/plain"}, {Name: "App-Name", Value: "pf-launcher"}},
+		Data:   []byte("hello world"),
+	}
+	message, err := item.signatureData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Signature, err = key.Sign(message); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func TestDataItemVector(t *testing.T) {
+	item := vectorDataItem(t)
+
+	if got := hex.EncodeToString(item.Owner[:]); got != vectorOwner {
+		t.Errorf("owner = %s, want %s", got, vectorOwner)
+	}
+	tags, err := encodeTags(item.Tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(tags); got != vectorTags {
+		t.Errorf("tags = %s, want %s", got, vectorTags)
+	}
+	message, err := item.signatureData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(message); got != vectorDeepHash {
+		t.Errorf("deep hash = %s, want %s", got, vectorDeepHash)
+	}
+	if got := hex.EncodeToString(item.Signature[:]); got != vectorSignature {
+		t.Errorf("signature = %s, want %s", got, vectorSignature)
+	}
+	if got := item.ID(); got != vectorID {
+		t.Errorf("ID = %s, want %s", got, vectorID)
+	}
+	data, err := item.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(data); got != vectorItem {
+		t.Errorf("Bytes = %s, want %s", got, vectorItem)
+	}
+}
+
+func TestNewDataItem(t *testing.T) {
+	key := vectorKey()
+	a, err := NewDataItem(key, []byte("hello world"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewDataItem(key, []byte("hello world"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID() == b.ID() {
+		t.Error("the same content got the same id twice, the anchor is not random")
+	}
+
+	message, err := a.signatureData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(a.Owner[:]), message, a.Signature[:]) {
+		t.Error("signature does not verify against the owner")
+	}
+}
+
+func TestEncodeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []Tag
+		want string
+	}{
+		{"none", nil, ""},
+		// count 1 zigzags to 0x02, lengths 12 and 10 to 0x18 and 0x14, then
+		// the zero block count ends the array.
+		{"one", []Tag{{Name: "Content-Type", Value: "text/plain"}}, "02" + "18" + hex.EncodeToString([]byte("Content-Type")) + "14" + hex.EncodeToString([]byte("text/plain")) + "00"},
+		// A 64 byte value needs a two byte varint, 128 zigzagged.
+		{"long value", []Tag{{Name: "a", Value: string(bytes.Repeat([]byte{'x'}, 64))}}, "02" + "02" + "61" + "8001" + hex.EncodeToString(bytes.Repeat([]byte{'x'}, 64)) + "00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeTags(tt.tags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("encodeTags = %x, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := encodeTags([]Tag{{Name: "", Value: "x"}}); err == nil {
+		t.Error("empty tag name accepted")
+	}
+	if _, err := encodeTags(make([]Tag, 129)); err == nil {
+		t.Error("129 tags accepted")
+	}
+}
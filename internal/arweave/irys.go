@@ -0,0 +1,220 @@
+package arweave
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/storage"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var _ storage.Storage = (*Client)(nil)
+
+const (
+	DefaultNodeURL    = "https://uploader.irys.xyz"
+	DefaultGatewayURL = "https://arweave.net"
+
+	// token is the currency Irys charges uploads to.
+	token = "solana"
+)
+
+// ErrPermanent is returned by Unpin, data on Arweave can't be removed.
+var ErrPermanent = errors.New("arweave data is permanent")
+
+// Client uploads to Arweave through an Irys bundler node, paying with the
+// balance the Solana key has funded on the node.
+type Client struct {
+	NodeURL    string
+	GatewayURL string
+	Key        solana.PrivateKey
+	Client     *http.Client
+}
+
+func NewClient(nodeURL, gatewayURL string, key solana.PrivateKey) *Client {
+	if nodeURL == "" {
+		nodeURL = DefaultNodeURL
+	}
+	if gatewayURL == "" {
+		gatewayURL = DefaultGatewayURL
+	}
+	return &Client{
+		NodeURL:    strings.TrimSuffix(nodeURL, "/"),
+		GatewayURL: strings.TrimSuffix(gatewayURL, "/"),
+		Key:        key,
+		Client:     &http.Client{},
+	}
+}
+
+// Put signs the content as a data item tagged with its content type and
+// uploads it.
+func (c *Client) Put(ctx context.Context, name string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	item, err := NewDataItem(c.Key, data, []Tag{{Name: "Content-Type", Value: contentType}})
+	if err != nil {
+		return "", err
+	}
+	body, err := item.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	err = c.call(ctx, "POST", "/tx/"+token, body, "application/octet-stream", &result)
+	var httpErr *retry.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusPaymentRequired {
+		return "", fmt.Errorf("not enough Irys balance to upload %d bytes, fund it first: %w", len(body), err)
+	}
+	if err != nil {
+		return "", err
+	}
+	if result.ID != item.ID() {
+		return "", fmt.Errorf("irys returned id %s for data item %s", result.ID, item.ID())
+	}
+	return result.ID, nil
+}
+
+// URI links the canonical gateway whichever gateway Stat uses.
+func (c *Client) URI(id string) string {
+	return DefaultGatewayURL + "/" + id
+}
+
+// Pin is a no-op, Arweave data stays without pinning.
+func (c *Client) Pin(ctx context.Context, id string) error {
+	return nil
+}
+
+func (c *Client) Unpin(ctx context.Context, id string) error {
+	return ErrPermanent
+}
+
+// Stat asks the gateway for the data. Uploads are served by Irys right away
+// but can take a while to reach other gateways.
+func (c *Client) Stat(ctx context.Context, id string) (*storage.Object, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.GatewayURL+"/"+id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", id, storage.ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gateway error: %s", resp.Status)
+	}
+	return &storage.Object{CID: id, Size: resp.ContentLength, Pinned: true}, nil
+}
+
+// Price returns the lamports Irys charges to upload size bytes.
+func (c *Client) Price(ctx context.Context, size int) (uint64, error) {
+	var price json.Number
+	if err := c.call(ctx, "GET", "/price/"+token+"/"+strconv.Itoa(size), nil, "", &price); err != nil {
+		return 0, err
+	}
+	return parseLamports(price.String())
+}
+
+// Balance returns the lamports the key has funded on the node.
+func (c *Client) Balance(ctx context.Context) (uint64, error) {
+	var result struct {
+		Balance json.Number `json:"balance"`
+	}
+	path := "/account/balance/" + token + "?address=" + c.Key.PublicKey().String()
+	if err := c.call(ctx, "GET", path, nil, "", &result); err != nil {
+		return 0, err
+	}
+	return parseLamports(result.Balance.String())
+}
+
+// FundingAddress is the node's wallet that funding transfers are sent to.
+func (c *Client) FundingAddress(ctx context.Context) (solana.PublicKey, error) {
+	var info struct {
+		Addresses map[string]string `json:"addresses"`
+	}
+	if err := c.call(ctx, "GET", "/info", nil, "", &info); err != nil {
+		return solana.PublicKey{}, err
+	}
+	address, ok := info.Addresses[token]
+	if !ok {
+		return solana.PublicKey{}, fmt.Errorf("irys node has no %s address", token)
+	}
+	return solana.PublicKeyFromBase58(address)
+}
+
+// RegisterFunding tells the node about a confirmed transfer to its funding
+// address, so it is credited to the balance.
+func (c *Client) RegisterFunding(ctx context.Context, sig solana.Signature) error {
+	body, err := json.Marshal(map[string]string{"tx_id": sig.String()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.call(ctx, "POST", "/account/balance/"+token, body, "application/json", nil)
+}
+
+func parseLamports(value string) (uint64, error) {
+	lamports, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q from irys", value)
+	}
+	return lamports, nil
+}
+
+func (c *Client) call(ctx context.Context, method, path string, body []byte, contentType string, out any) error {
+	resp, err := retry.Value(ctx, retry.Default, "irys "+path, func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, c.NodeURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if contentType != "" {
+			req.Header.Add("Content-Type", contentType)
+		}
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		// Irys answers 201 or 202 to a new upload and 200 to the rest.
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("irys API error: %w", retry.NewHTTPError(resp))
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package arweave
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pf-launcher/internal/retry"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// irysMock serves the Irys endpoints the client uses.
+type irysMock struct {
+	t        *testing.T
+	owner    solana.PublicKey
+	funding  solana.PublicKey
+	balance  string
+	funded   []string
+	uploads  [][]byte
+	badID    bool
+	unfunded bool
+}
+
+func (m *irysMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/price/solana/1024":
+		io.WriteString(w, "5000")
+	case r.Method == "GET" && r.URL.Path == "/account/balance/solana":
+		if got := r.URL.Query().Get("address"); got != m.owner.String() {
+			m.t.Errorf("balance asked for %s, want %s", got, m.owner)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"balance": m.balance})
+	case r.Method == "GET" && r.URL.Path == "/info":
+		json.NewEncoder(w).Encode(map[string]any{"addresses": map[string]string{"solana": m.funding.String()}})
+	case r.Method == "POST" && r.URL.Path == "/account/balance/solana":
+		var body struct {
+			TxID string `json:"tx_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m.funded = append(m.funded, body.TxID)
+	case r.Method == "POST" && r.URL.Path == "/tx/solana":
+		if m.unfunded {
+			http.Error(w, "Not enough balance for transaction", http.StatusPaymentRequired)
+			return
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/octet-stream" {
+			m.t.Errorf("upload content type = %q", got)
+		}
+		data, _ := io.ReadAll(r.Body)
+		if len(data) < 2+64 || binary.LittleEndian.Uint16(data) != SignatureTypeSolana {
+			http.Error(w, "not a solana data item", http.StatusBadRequest)
+			return
+		}
+		m.uploads = append(m.uploads, data)
+		sum := sha256.Sum256(data[2 : 2+64])
+		id := base64.RawURLEncoding.EncodeToString(sum[:])
+		if m.badID {
+			id = "not-" + id
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newMockClient(t *testing.T) (*Client, *irysMock) {
+	t.Helper()
+	key := vectorKey()
+	mock := &irysMock{t: t, owner: key.PublicKey(), funding: solana.NewWallet().PublicKey(), balance: "12345"}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, server.URL, key), mock
+}
+
+func TestPriceAndBalance(t *testing.T) {
+	client, _ := newMockClient(t)
+	ctx := context.Background()
+
+	price, err := client.Price(ctx, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price != 5000 {
+		t.Errorf("Price = %d, want 5000", price)
+	}
+
+	balance, err := client.Balance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 12345 {
+		t.Errorf("Balance = %d, want 12345", balance)
+	}
+}
+
+func TestBalanceRejectsFractions(t *testing.T) {
+	client, mock := newMockClient(t)
+	mock.balance = "1.5"
+	if _, err := client.Balance(context.Background()); err == nil {
+		t.Error("fractional balance accepted")
+	}
+}
+
+func TestFund(t *testing.T) {
+	client, mock := newMockClient(t)
+	ctx := context.Background()
+
+	address, err := client.FundingAddress(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !address.Equals(mock.funding) {
+		t.Errorf("FundingAddress = %s, want %s", address, mock.funding)
+	}
+
+	sig := solana.Signature{1, 2, 3}
+	if err := client.RegisterFunding(ctx, sig); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.funded) != 1 || mock.funded[0] != sig.String() {
+		t.Errorf("registered %v, want [%s]", mock.funded, sig)
+	}
+}
+
+func TestPut(t *testing.T) {
+	client, mock := newMockClient(t)
+
+	id, err := client.Put(context.Background(), "metadata.json", strings.NewReader(`{"name":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.uploads) != 1 {
+		t.Fatalf("%d uploads, want 1", len(mock.uploads))
+	}
+	if got := client.URI(id); got != DefaultGatewayURL+"/"+id {
+		t.Errorf("URI = %s", got)
+	}
+
+	upload := mock.uploads[0]
+	if !strings.HasSuffix(string(upload), `{"name":"x"}`) {
+		t.Error("upload does not end with the data")
+	}
+	// The value length 16 zigzags to 0x20.
+	if !strings.Contains(string(upload), "Content-Type\x20application/json") {
+		t.Error("upload is not tagged with the content type of its name")
+	}
+}
+
+func TestPutChecksID(t *testing.T) {
+	client, mock := newMockClient(t)
+	mock.badID = true
+	if _, err := client.Put(context.Background(), "image.png", strings.NewReader("png")); err == nil {
+		t.Error("mismatched id accepted")
+	}
+}
+
+func TestPutUnfunded(t *testing.T) {
+	client, mock := newMockClient(t)
+	mock.unfunded = true
+
+	_, err := client.Put(context.Background(), "image.png", strings.NewReader("png"))
+	var httpErr *retry.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("Put = %v, want a 402", err)
+	}
+	if !strings.Contains(err.Error(), "fund it first") {
+		t.Errorf("error %q does not say to fund", err)
+	}
+}
+
+func TestUnpin(t *testing.T) {
+	client, _ := newMockClient(t)
+	if err := client.Unpin(context.Background(), "id"); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Unpin = %v, want ErrPermanent", err)
+	}
+}
@@ -0,0 +1,269 @@
+package batch
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusUploaded  = "uploaded"
+	StatusLaunching = "launching"
+	StatusLaunched  = "launched"
+	StatusFailed    = "failed"
+)
+
+// Item is one token in a manifest.
+type Item struct {
+	Name        string  `json:"name"`
+	Symbol      string  `json:"symbol"`
+	Description string  `json:"description"`
+	Twitter     string  `json:"twitter"`
+	Telegram    string  `json:"telegram"`
+	Website     string  `json:"website"`
+	Image       string  `json:"image"`
+	BuySol      float64 `json:"buySol"`
+}
+
+// Entry is an item with its progress. Signature and LastValidBlockHeight are
+// saved before sending, so a resumed run can tell whether it may still land.
+type Entry struct {
+	Item
+	Status               string    `json:"status"`
+	MetadataURI          string    `json:"metadataUri,omitempty"`
+	Mint                 string    `json:"mint,omitempty"`
+	Signature            string    `json:"signature,omitempty"`
+	LastValidBlockHeight uint64    `json:"lastValidBlockHeight,omitempty"`
+	TokensReceived       uint64    `json:"tokensReceived,omitempty"`
+	SolSpent             uint64    `json:"solSpent,omitempty"`
+	Error                string    `json:"error,omitempty"`
+	UpdatedAt            time.Time `json:"updatedAt"`
+}
+
+// LoadManifest reads items from a JSON array or a CSV file with a header
+// row naming the Item fields.
+func LoadManifest(path string) ([]Item, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	var items []Item
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.NewDecoder(file).Decode(&items); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+	case ".csv":
+		items, err = readCSV(file)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("manifest must be .json or .csv, got %s", path)
+	}
+
+	// Relative image paths are relative to the manifest.
+	for i := range items {
+		if items[i].Name == "" || items[i].Symbol == "" || items[i].Image == "" {
+			return nil, fmt.Errorf("manifest item %d needs a name, symbol and image", i+1)
+		}
+		if !filepath.IsAbs(items[i].Image) {
+			items[i].Image = filepath.Join(filepath.Dir(path), items[i].Image)
+		}
+	}
+	return items, nil
+}
+
+func readCSV(r io.Reader) ([]Item, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	var items []Item
+	for line, record := range records[1:] {
+		var item Item
+		for i, column := range header {
+			value := strings.TrimSpace(record[i])
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "name":
+				item.Name = value
+			case "symbol":
+				item.Symbol = value
+			case "description":
+				item.Description = value
+			case "twitter":
+				item.Twitter = value
+			case "telegram":
+				item.Telegram = value
+			case "website":
+				item.Website = value
+			case "image":
+				item.Image = value
+			case "buysol", "buy":
+				if value == "" {
+					continue
+				}
+				item.BuySol, err = strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("manifest line %d: invalid buy amount %q", line+2, value)
+				}
+			default:
+				return nil, fmt.Errorf("manifest has unknown column %q", column)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Batch is the progress of a manifest, saved after every change so a run can
+// resume where the last one stopped.
+type Batch struct {
+	mu        sync.Mutex
+	statePath string
+
+	Manifest string   `json:"manifest"`
+	Entries  []*Entry `json:"entries"`
+}
+
+// Open loads the state saved at statePath, or starts a new one for items. A
+// saved state must describe the same items in the same order.
+func Open(manifest string, items []Item, statePath string) (*Batch, error) {
+	b := &Batch{statePath: statePath, Manifest: manifest}
+
+	data, err := os.ReadFile(statePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		for _, item := range items {
+			b.Entries = append(b.Entries, &Entry{Item: item, Status: StatusPending})
+		}
+		return b, b.save()
+	case err != nil:
+		return nil, fmt.Errorf("failed to read batch state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse batch state: %w", err)
+	}
+	if len(b.Entries) != len(items) {
+		return nil, fmt.Errorf("batch state %s has %d items but the manifest has %d", statePath, len(b.Entries), len(items))
+	}
+	for i, entry := range b.Entries {
+		if entry.Name != items[i].Name || entry.Symbol != items[i].Symbol {
+			return nil, fmt.Errorf("batch state %s item %d is %s, manifest has %s", statePath, i+1, entry.Symbol, items[i].Symbol)
+		}
+	}
+	return b, nil
+}
+
+// Update applies fn to entry and saves the state.
+func (b *Batch) Update(entry *Entry, fn func(e *Entry)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fn(entry)
+	entry.UpdatedAt = time.Now().UTC()
+	return b.save()
+}
+
+func (b *Batch) save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch state: %w", err)
+	}
+	tmp := b.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write batch state: %w", err)
+	}
+	if err := os.Rename(tmp, b.statePath); err != nil {
+		return fmt.Errorf("failed to write batch state: %w", err)
+	}
+	return nil
+}
+
+// Run calls fn for every entry that has not launched, at most concurrency at
+// a time, until all are done or ctx is cancelled.
+func (b *Batch) Run(ctx context.Context, concurrency int, fn func(ctx context.Context, entry *Entry)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	work := make(chan *Entry)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range work {
+				fn(ctx, entry)
+			}
+		}()
+	}
+
+feed:
+	for _, entry := range b.Entries {
+		if entry.Status == StatusLaunched {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case work <- entry:
+		}
+	}
+	close(work)
+	wg.Wait()
+}
+
+// Counts returns how many entries are in each status.
+func (b *Batch) Counts() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := make(map[string]int)
+	for _, entry := range b.Entries {
+		counts[entry.Status]++
+	}
+	return counts
+}
+
+// WriteReport writes one CSV row per entry.
+func (b *Batch) WriteReport(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"name", "symbol", "status", "mint", "signature", "metadata_uri", "tokens_received", "sol_spent_lamports", "error"})
+	for _, e := range b.Entries {
+		w.Write([]string{
+			e.Name, e.Symbol, e.Status, e.Mint, e.Signature, e.MetadataURI,
+			strconv.FormatUint(e.TokensReceived, 10), strconv.FormatUint(e.SolSpent, 10), e.Error,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -3,9 +3,23 @@ package internal
 const (
 	PUMP_FUN_PROGRAM = "6EF8rrecthR5Dkzon8Nwu78hRvfCKubJ14M5uBEwF6P"
 	FEE_RECIPIENT    = "62qc2CNXwrYqQScmEdiZFFAnJR262PxWEuNQtxfafNgV"
+	EVENT_AUTHORITY  = "Ce6TQqeHC9p8KetsN6JsjHK7UTZk7nasjjnr7XxXp9F1"
 	BUY_AMOUNT       = 0.001
 	SOL_USD_PRICE    = 175
 	SLIPPAGE         = 0.30
 
 	MARKET_CAP_THRESHOLD = 8000.0
+
+	// Sizes in bytes of the accounts a launch creates.
+	MINT_ACCOUNT_SIZE     = 82
+	TOKEN_ACCOUNT_SIZE    = 165
+	BONDING_CURVE_SIZE    = 150
+	METADATA_ACCOUNT_SIZE = 679
+
+	LAMPORTS_PER_SIGNATURE = 5000
+
+	// Byte limits the metadata program puts on a token's create data.
+	MAX_NAME_LENGTH   = 32
+	MAX_SYMBOL_LENGTH = 10
+	MAX_URI_LENGTH    = 200
 )
@@ -0,0 +1,166 @@
+package decode
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"pf-launcher/internal"
+	"pf-launcher/internal/programs"
+	"pf-launcher/internal/txerrors"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const programDataLog = "Program data: "
+
+type Instruction struct {
+	// Index is the top-level instruction, Inner is -1 for the top-level
+	// instruction itself and the position inside it otherwise.
+	Index   int
+	Inner   int
+	Program solana.PublicKey
+	Decoded *programs.DecodedInstruction
+	Err     error
+}
+
+type Transaction struct {
+	Signatures   []solana.Signature
+	Instructions []Instruction
+	Events       []programs.DecodedEvent
+	Err          *txerrors.TransactionError
+	Logs         []string
+}
+
+// Decode labels the pump.fun instructions of tx. When meta is set, inner
+// instructions and events are decoded too. Events come from emit_cpi! inner
+// instructions, or from logs when there are none, so an event the program
+// both logs and emits is only counted once.
+func Decode(tx *solana.Transaction, meta *rpc.TransactionMeta) (*Transaction, error) {
+	keys := tx.Message.AccountKeys
+	if meta != nil {
+		// Addresses loaded from lookup tables follow the static keys,
+		// writable first.
+		keys = append(append(append(solana.PublicKeySlice{}, keys...), meta.LoadedAddresses.Writable...), meta.LoadedAddresses.ReadOnly...)
+	}
+
+	decoded := &Transaction{Signatures: tx.Signatures}
+	for i, compiled := range tx.Message.Instructions {
+		ix, err := decodeCompiled(keys, compiled)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		ix.Index, ix.Inner = i, -1
+		decoded.Instructions = append(decoded.Instructions, ix)
+
+		if meta == nil {
+			continue
+		}
+		for _, inner := range meta.InnerInstructions {
+			if int(inner.Index) != i {
+				continue
+			}
+			for j, compiledInner := range inner.Instructions {
+				innerIx, err := decodeCompiled(keys, compiledInner)
+				if err != nil {
+					return nil, fmt.Errorf("instruction %d.%d: %w", i, j, err)
+				}
+				innerIx.Index, innerIx.Inner = i, j
+				decoded.Instructions = append(decoded.Instructions, innerIx)
+
+				if innerIx.Decoded != nil && innerIx.Decoded.Accounts == nil {
+					if event, ok := eventFromInstruction(innerIx.Decoded); ok {
+						decoded.Events = append(decoded.Events, event)
+					}
+				}
+			}
+		}
+	}
+
+	if meta != nil {
+		decoded.Err = txerrors.FromMetaErr(meta.Err, tx, meta.LogMessages)
+		decoded.Logs = meta.LogMessages
+		if len(decoded.Events) > 0 {
+			return decoded, nil
+		}
+		for _, line := range meta.LogMessages {
+			if !strings.HasPrefix(line, programDataLog) {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, programDataLog))
+			if err != nil {
+				continue
+			}
+			if event, err := programs.DecodeEvent(data); err == nil {
+				decoded.Events = append(decoded.Events, *event)
+			}
+		}
+	}
+
+	return decoded, nil
+}
+
+func decodeCompiled(keys solana.PublicKeySlice, compiled solana.CompiledInstruction) (Instruction, error) {
+	if int(compiled.ProgramIDIndex) >= len(keys) {
+		return Instruction{}, fmt.Errorf("program index %d out of range", compiled.ProgramIDIndex)
+	}
+
+	ix := Instruction{Program: keys[compiled.ProgramIDIndex]}
+	if !ix.Program.Equals(solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)) {
+		return ix, nil
+	}
+
+	accounts := make([]solana.PublicKey, 0, len(compiled.Accounts))
+	for _, index := range compiled.Accounts {
+		if int(index) >= len(keys) {
+			return Instruction{}, fmt.Errorf("account index %d out of range", index)
+		}
+		accounts = append(accounts, keys[index])
+	}
+
+	ix.Decoded, ix.Err = programs.DecodeInstruction(accounts, compiled.Data)
+	return ix, nil
+}
+
+func eventFromInstruction(ix *programs.DecodedInstruction) (programs.DecodedEvent, bool) {
+	name, ok := strings.CutPrefix(ix.Name, "emit ")
+	if !ok {
+		return programs.DecodedEvent{}, false
+	}
+	return programs.DecodedEvent{Name: name, Event: ix.Args}, true
+}
+
+func (t *Transaction) String() string {
+	var b strings.Builder
+	if len(t.Signatures) > 0 {
+		fmt.Fprintf(&b, "signature: %s\n", t.Signatures[0])
+	}
+	if t.Err != nil {
+		fmt.Fprintf(&b, "error: %v\n", t.Err)
+	}
+
+	for _, ix := range t.Instructions {
+		indent, position := "", fmt.Sprintf("#%d", ix.Index)
+		if ix.Inner >= 0 {
+			indent, position = "  ", fmt.Sprintf("#%d.%d", ix.Index, ix.Inner)
+		}
+
+		switch {
+		case ix.Err != nil:
+			fmt.Fprintf(&b, "%s%s pump.fun: %v\n", indent, position, ix.Err)
+		case ix.Decoded == nil:
+			fmt.Fprintf(&b, "%s%s program %s\n", indent, position, ix.Program)
+		default:
+			fmt.Fprintf(&b, "%s%s pump.fun %s %+v\n", indent, position, ix.Decoded.Name, ix.Decoded.Args)
+			for _, account := range ix.Decoded.Accounts {
+				fmt.Fprintf(&b, "%s    %-26s %s\n", indent, account.Role, account.PublicKey)
+			}
+		}
+	}
+
+	for _, event := range t.Events {
+		fmt.Fprintf(&b, "event %s %+v\n", event.Name, event.Event)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
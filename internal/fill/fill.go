@@ -0,0 +1,254 @@
+package fill
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"pf-launcher/internal"
+	"pf-launcher/internal/decode"
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// DefaultMaxDeviationBps is how much worse than quoted a fill may be before
+// it is flagged.
+const DefaultMaxDeviationBps = 300
+
+// MaxDeviationFromEnv reads MAX_FILL_DEVIATION_BPS, falling back to
+// DefaultMaxDeviationBps.
+func MaxDeviationFromEnv() (int64, error) {
+	value := os.Getenv("MAX_FILL_DEVIATION_BPS")
+	if value == "" {
+		return DefaultMaxDeviationBps, nil
+	}
+	bps, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid MAX_FILL_DEVIATION_BPS %q: %w", value, err)
+	}
+	return bps, nil
+}
+
+// Quote is what a trade was expected to do when it was built. For buys Sol is
+// the lamports expected to be paid to the curve with the protocol fee, for
+// sells the lamports expected back after it. Limit is the max SOL cost or
+// min SOL output written into the instruction.
+type Quote struct {
+	IsBuy  bool   `json:"isBuy"`
+	Tokens uint64 `json:"tokens"`
+	Sol    uint64 `json:"sol"`
+	Limit  uint64 `json:"limit"`
+}
+
+// Fill is the outcome of a confirmed trade, read from its metadata.
+type Fill struct {
+	Signature solana.Signature
+	Mint      solana.PublicKey
+	IsBuy     bool
+	// Tokens is the raw token amount the owner gained or gave up.
+	Tokens uint64
+	// CurveSol is the SOL that went into or came out of the bonding curve.
+	CurveSol uint64
+	// TradingFee is what pump.fun and the creator took on top of CurveSol.
+	TradingFee  uint64
+	BaseFee     uint64
+	PriorityFee uint64
+	// Rent is what the owner paid to create accounts, such as the ATA.
+	Rent uint64
+	// SolDelta is the owner's net lamport change, negative when paying.
+	SolDelta int64
+	Trades   []types.TradeEvent
+}
+
+// FromTransaction computes the fill of owner's trade of mint in a confirmed
+// transaction.
+func FromTransaction(tx *solana.Transaction, meta *rpc.TransactionMeta, owner, mint solana.PublicKey) (*Fill, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("transaction has no metadata")
+	}
+	if meta.Err != nil {
+		return nil, fmt.Errorf("transaction failed: %v", meta.Err)
+	}
+
+	decoded, err := decode.Decode(tx, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fill{Signature: tx.Signatures[0], Mint: mint}
+
+	keys := append(append(append(solana.PublicKeySlice{}, tx.Message.AccountKeys...), meta.LoadedAddresses.Writable...), meta.LoadedAddresses.ReadOnly...)
+	ownerIndex := -1
+	for i, key := range keys {
+		if key.Equals(owner) {
+			ownerIndex = i
+			break
+		}
+	}
+	if ownerIndex < 0 || ownerIndex >= len(meta.PreBalances) || ownerIndex >= len(meta.PostBalances) {
+		return nil, fmt.Errorf("owner %s is not part of the transaction", owner)
+	}
+	f.SolDelta = int64(meta.PostBalances[ownerIndex]) - int64(meta.PreBalances[ownerIndex])
+
+	pre, err := tokenBalance(meta.PreTokenBalances, owner, mint)
+	if err != nil {
+		return nil, err
+	}
+	post, err := tokenBalance(meta.PostTokenBalances, owner, mint)
+	if err != nil {
+		return nil, err
+	}
+	f.IsBuy = post >= pre
+	if f.IsBuy {
+		f.Tokens = post - pre
+	} else {
+		f.Tokens = pre - post
+	}
+
+	for _, event := range decoded.Events {
+		trade, ok := event.Event.(types.TradeEvent)
+		if !ok || !trade.Mint.Equals(mint) || !trade.User.Equals(owner) {
+			continue
+		}
+		f.Trades = append(f.Trades, trade)
+		f.CurveSol += trade.SolAmount
+		f.IsBuy = trade.IsBuy
+	}
+
+	// Only the fee payer is charged fees.
+	if ownerIndex == 0 {
+		f.BaseFee = uint64(len(tx.Signatures)) * internal.LAMPORTS_PER_SIGNATURE
+		if meta.Fee > f.BaseFee {
+			f.PriorityFee = meta.Fee - f.BaseFee
+		} else {
+			f.BaseFee = meta.Fee
+		}
+	}
+	f.Rent = rent(decoded, keys, meta, f)
+
+	// Whatever the owner paid beyond the curve, fees and rent went to the
+	// protocol fee recipient and creator vault.
+	fixed := int64(f.BaseFee + f.PriorityFee + f.Rent)
+	var trading int64
+	if f.IsBuy {
+		trading = -f.SolDelta - fixed - int64(f.CurveSol)
+	} else {
+		trading = int64(f.CurveSol) - f.SolDelta - fixed
+	}
+	if len(f.Trades) > 0 && trading > 0 {
+		f.TradingFee = uint64(trading)
+	}
+
+	return f, nil
+}
+
+// rent sums the balances of accounts the transaction created, leaving out
+// fee destinations and the SOL a buy moved into a bonding curve created in
+// the same transaction.
+func rent(decoded *decode.Transaction, keys solana.PublicKeySlice, meta *rpc.TransactionMeta, f *Fill) uint64 {
+	skip := make(map[solana.PublicKey]bool)
+	var curves []solana.PublicKey
+	for _, ix := range decoded.Instructions {
+		if ix.Decoded == nil {
+			continue
+		}
+		for _, account := range ix.Decoded.Accounts {
+			switch account.Role {
+			case "fee_recipient", "creator_vault":
+				skip[account.PublicKey] = true
+			case "bonding_curve":
+				if ix.Decoded.Name == "buy" {
+					curves = append(curves, account.PublicKey)
+				}
+			}
+		}
+	}
+
+	var total uint64
+	for i, key := range keys {
+		if i >= len(meta.PreBalances) || i >= len(meta.PostBalances) || skip[key] {
+			continue
+		}
+		if meta.PreBalances[i] != 0 || meta.PostBalances[i] == 0 {
+			continue
+		}
+		created := meta.PostBalances[i]
+		for _, curve := range curves {
+			if curve.Equals(key) && created >= f.CurveSol {
+				created -= f.CurveSol
+			}
+		}
+		total += created
+	}
+	return total
+}
+
+func tokenBalance(balances []rpc.TokenBalance, owner, mint solana.PublicKey) (uint64, error) {
+	for _, balance := range balances {
+		if balance.Owner == nil || !balance.Owner.Equals(owner) || !balance.Mint.Equals(mint) || balance.UiTokenAmount == nil {
+			continue
+		}
+		amount, err := strconv.ParseUint(balance.UiTokenAmount.Amount, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid token amount %q: %w", balance.UiTokenAmount.Amount, err)
+		}
+		return amount, nil
+	}
+	return 0, nil
+}
+
+// Deviation compares a fill with its quote.
+type Deviation struct {
+	Expected uint64
+	Actual   uint64
+	// Bps is how much worse than quoted the fill was, negative when better.
+	Bps int64
+	// Suspicious is set when the fill was worse than allowed, which usually
+	// means someone traded ahead of us in the same block.
+	Suspicious bool
+}
+
+// Compare measures the SOL side of f against q. Token amounts are fixed by
+// the instruction for buys and sells alike, so price movement shows in SOL.
+func (f *Fill) Compare(q Quote, maxDeviationBps int64) Deviation {
+	d := Deviation{Expected: q.Sol}
+	if f.IsBuy {
+		d.Actual = f.CurveSol + f.TradingFee
+	} else if f.CurveSol > f.TradingFee {
+		d.Actual = f.CurveSol - f.TradingFee
+	}
+	if q.Sol == 0 {
+		return d
+	}
+
+	diff := int64(d.Actual) - int64(d.Expected)
+	if !f.IsBuy {
+		diff = -diff
+	}
+	d.Bps = diff * 10000 / int64(d.Expected)
+	d.Suspicious = d.Bps > maxDeviationBps
+	return d
+}
+
+func (f *Fill) String() string {
+	var b strings.Builder
+	side := "sold"
+	if f.IsBuy {
+		side = "bought"
+	}
+	fmt.Fprintf(&b, "%s %d tokens of %s, curve %d lamports\n", side, f.Tokens, f.Mint, f.CurveSol)
+	fmt.Fprintf(&b, "  trading fee %d, base fee %d, priority fee %d, rent %d\n", f.TradingFee, f.BaseFee, f.PriorityFee, f.Rent)
+	fmt.Fprintf(&b, "  net SOL change %d lamports", f.SolDelta)
+	return b.String()
+}
+
+func (d Deviation) String() string {
+	s := fmt.Sprintf("quoted %d lamports, filled at %d (%+d bps)", d.Expected, d.Actual, d.Bps)
+	if d.Suspicious {
+		s += ", worse than allowed, possible front-running"
+	}
+	return s
+}
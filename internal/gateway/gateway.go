@@ -0,0 +1,161 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"pf-launcher/internal/ipfs"
+	"pf-launcher/internal/types"
+)
+
+const (
+	DefaultGateways = "https://gateway.pinata.cloud/ipfs/,https://ipfs.io/ipfs/,https://dweb.link/ipfs/"
+
+	requestTimeout = 10 * time.Second
+	retryInterval  = 3 * time.Second
+	maxBodySize    = 32 << 20
+)
+
+// Result is how one gateway did.
+type Result struct {
+	Gateway string
+	Elapsed time.Duration
+	Err     error
+}
+
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s failed after %s: %v", r.Gateway, r.Elapsed.Round(time.Millisecond), r.Err)
+	}
+	return fmt.Sprintf("%s served metadata and image in %s", r.Gateway, r.Elapsed.Round(time.Millisecond))
+}
+
+// Verifier checks that token metadata and its image can be fetched through
+// public gateways, so a launch does not show up with a blank image.
+type Verifier struct {
+	// Gateways are URL prefixes a CID is appended to, like
+	// https://ipfs.io/ipfs/.
+	Gateways []string
+	Required int
+	Client   *http.Client
+}
+
+// NewVerifier takes a comma separated gateway list, DefaultGateways when
+// empty.
+func NewVerifier(gateways string, required int) *Verifier {
+	if gateways == "" {
+		gateways = DefaultGateways
+	}
+	v := &Verifier{Required: required, Client: &http.Client{}}
+	for _, gateway := range strings.Split(gateways, ",") {
+		if gateway = strings.TrimSpace(gateway); gateway != "" {
+			v.Gateways = append(v.Gateways, strings.TrimSuffix(gateway, "/")+"/")
+		}
+	}
+	return v
+}
+
+// Wait retries every gateway until Required of them have served the
+// metadata at metadataURI and the image it links, both matching their CIDs,
+// or ctx is done.
+func (v *Verifier) Wait(ctx context.Context, metadataURI string) ([]Result, error) {
+	if v.Required > len(v.Gateways) {
+		return nil, fmt.Errorf("%d gateways required but only %d configured", v.Required, len(v.Gateways))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan Result, len(v.Gateways))
+	for _, gateway := range v.Gateways {
+		go func(gateway string) {
+			results <- v.poll(ctx, gateway, metadataURI)
+		}(gateway)
+	}
+
+	var all []Result
+	served := 0
+	for range v.Gateways {
+		result := <-results
+		all = append(all, result)
+		if result.Err == nil {
+			if served++; served >= v.Required {
+				return all, nil
+			}
+		}
+	}
+	return all, fmt.Errorf("only %d of the %d required gateways served %s", served, v.Required, metadataURI)
+}
+
+func (v *Verifier) poll(ctx context.Context, gateway, metadataURI string) Result {
+	start := time.Now()
+	for {
+		err := v.check(ctx, gateway, metadataURI)
+		if err == nil || ctx.Err() != nil {
+			return Result{Gateway: gateway, Elapsed: time.Since(start), Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			return Result{Gateway: gateway, Elapsed: time.Since(start), Err: err}
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (v *Verifier) check(ctx context.Context, gateway, metadataURI string) error {
+	data, err := v.fetch(ctx, gateway, metadataURI)
+	if err != nil {
+		return fmt.Errorf("metadata: %w", err)
+	}
+	var metadata types.Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("metadata is not JSON: %w", err)
+	}
+	if metadata.Image == "" {
+		return fmt.Errorf("metadata has no image")
+	}
+	if _, err := v.fetch(ctx, gateway, metadata.Image); err != nil {
+		return fmt.Errorf("image: %w", err)
+	}
+	return nil
+}
+
+// fetch gets an ipfs:// URI through gateway and checks the content hashes
+// to its CID. Other URIs are fetched as they are.
+func (v *Verifier) fetch(ctx context.Context, gateway, uri string) ([]byte, error) {
+	cid, isIPFS := strings.CutPrefix(uri, "ipfs://")
+	url := uri
+	if isIPFS {
+		url = gateway + cid
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	if isIPFS {
+		if got := ipfs.CID(data); !ipfs.Equal(got, cid) {
+			return nil, fmt.Errorf("served content hashes to %s, not %s", got, cid)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,220 @@
+package imageprep
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	DefaultMaxSize  = 1000
+	DefaultMaxBytes = 1 << 20
+
+	// Images are shrunk by this much, in percent, while they are over the
+	// byte budget, down to minSize.
+	shrinkPercent = 85
+	minSize       = 128
+)
+
+var jpegQualities = []int{90, 80, 70, 60}
+
+// Options control the output image. Zero values use the defaults.
+type Options struct {
+	MaxSize  int
+	MaxBytes int
+}
+
+// Result is a processed image, ready to upload.
+type Result struct {
+	Data   []byte
+	Format string
+	Width  int
+	Height int
+
+	OriginalFormat string
+	OriginalWidth  int
+	OriginalHeight int
+	OriginalBytes  int
+}
+
+func (r *Result) ContentType() string {
+	return "image/" + r.Format
+}
+
+// Ext is the file extension for the output format.
+func (r *Result) Ext() string {
+	if r.Format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + r.Format
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%dx%d %s, %s (from %dx%d %s, %s)", r.Width, r.Height, r.Format, formatBytes(len(r.Data)),
+		r.OriginalWidth, r.OriginalHeight, r.OriginalFormat, formatBytes(r.OriginalBytes))
+}
+
+func formatBytes(n int) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.2f MiB", float64(n)/(1<<20))
+}
+
+// Check reports whether the file at path is an image in a supported format.
+func Check(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return unsupported(err)
+	}
+	return checkFormat(format)
+}
+
+func unsupported(err error) error {
+	if errors.Is(err, image.ErrFormat) {
+		return fmt.Errorf("unsupported image format, use PNG, JPEG, GIF or WebP")
+	}
+	return fmt.Errorf("failed to read image: %w", err)
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "png", "jpeg", "gif", "webp":
+		return nil
+	}
+	return fmt.Errorf("unsupported image format %s, use PNG, JPEG, GIF or WebP", format)
+}
+
+// ProcessFile reads and processes the image at path.
+func ProcessFile(path string, opts Options) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return Process(data, opts)
+}
+
+// Process center-crops the image to a square no larger than MaxSize and
+// re-encodes it under MaxBytes. Re-encoding drops EXIF and every other
+// metadata block, after the EXIF orientation has been applied. Images with
+// transparency become PNG, the rest JPEG.
+//
+// Animated GIFs can't be cropped frame by frame, so they are passed through
+// only when they are already square and within both limits.
+func Process(data []byte, opts Options) (*Result, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, unsupported(err)
+	}
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+	result := &Result{
+		OriginalFormat: format,
+		OriginalWidth:  config.Width,
+		OriginalHeight: config.Height,
+		OriginalBytes:  len(data),
+	}
+
+	if format == "gif" {
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		if len(animation.Image) > 1 {
+			return passAnimated(result, data, opts)
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	crop := squareCrop(img.Bounds())
+	size := min(crop.Dx(), opts.MaxSize)
+	for {
+		dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+
+		encoded, format, err := encode(dst, opts.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(encoded) <= opts.MaxBytes {
+			result.Data, result.Format = encoded, format
+			result.Width, result.Height = size, size
+			return result, nil
+		}
+		if size <= minSize {
+			break
+		}
+		size = max(size*shrinkPercent/100, minSize)
+	}
+	return nil, fmt.Errorf("image does not fit in %s even at %dx%d", formatBytes(opts.MaxBytes), size, size)
+}
+
+// encode writes img as PNG when it has transparency, otherwise as JPEG at
+// the highest quality that fits in maxBytes, or the lowest tried.
+func encode(img *image.NRGBA, maxBytes int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if !img.Opaque() {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.Bytes(), "png", nil
+	}
+
+	for _, quality := range jpegQualities {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		if buf.Len() <= maxBytes {
+			break
+		}
+	}
+	return buf.Bytes(), "jpeg", nil
+}
+
+func passAnimated(result *Result, data []byte, opts Options) (*Result, error) {
+	w, h := result.OriginalWidth, result.OriginalHeight
+	if w != h || w > opts.MaxSize || len(data) > opts.MaxBytes {
+		return nil, fmt.Errorf("animated GIF is %dx%d and %s, it must be square, at most %dx%d and %s",
+			w, h, formatBytes(len(data)), opts.MaxSize, opts.MaxSize, formatBytes(opts.MaxBytes))
+	}
+	result.Data, result.Format = data, "gif"
+	result.Width, result.Height = w, h
+	return result, nil
+}
+
+// squareCrop is the largest centered square in r.
+func squareCrop(r image.Rectangle) image.Rectangle {
+	side := min(r.Dx(), r.Dy())
+	x := r.Min.X + (r.Dx()-side)/2
+	y := r.Min.Y + (r.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
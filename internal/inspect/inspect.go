@@ -0,0 +1,324 @@
+package inspect
+
+import (
+	"fmt"
+	"strings"
+
+	"pf-launcher/internal"
+	"pf-launcher/internal/programs"
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/near/borsh-go"
+)
+
+const (
+	defaultComputeUnits = 200_000
+	maxComputeUnits     = 1_400_000
+
+	// maxTradeFeeBasisPoints bounds the protocol and creator fees a buy can
+	// charge on top of max_sol_cost. It is deliberately above the live value.
+	maxTradeFeeBasisPoints = 200
+)
+
+// Instruction is the readable summary of one instruction.
+type Instruction struct {
+	Index   int
+	Program solana.PublicKey
+	Name    string
+	Summary string
+}
+
+// Rent is the rent-exempt minimum balance by account size, as the cluster
+// reports it through getMinimumBalanceForRentExemption.
+type Rent map[uint64]uint64
+
+// RentSizes are the account sizes Inspect needs rent for.
+var RentSizes = []uint64{internal.MINT_ACCOUNT_SIZE, internal.BONDING_CURVE_SIZE, internal.TOKEN_ACCOUNT_SIZE, internal.METADATA_ACCOUNT_SIZE}
+
+// BuiltinRent is the rent for RentSizes at the cluster's default rate: 3480
+// lamports per byte-year, two years for exemption, plus 128 bytes of account
+// overhead. Mainnet has never charged more.
+func BuiltinRent() Rent {
+	rent := make(Rent, len(RentSizes))
+	for _, size := range RentSizes {
+		rent[size] = (size + 128) * 3480 * 2
+	}
+	return rent
+}
+
+// Bounded returns r with each of RentSizes raised to at least its
+// BuiltinRent. A signer that can't ask the cluster uses it on the rent an
+// envelope carries, so an understated table can't shrink MaxOutflow.
+func (r Rent) Bounded() Rent {
+	bounded := make(Rent, len(r))
+	for size, lamports := range r {
+		bounded[size] = lamports
+	}
+	for size, lamports := range BuiltinRent() {
+		bounded[size] = max(bounded[size], lamports)
+	}
+	return bounded
+}
+
+// Report describes what a transaction does and what it can cost its signers.
+type Report struct {
+	Instructions []Instruction
+	Programs     []solana.PublicKey
+	Signers      []solana.PublicKey
+	// MaxOutflow is the most lamports each signer can lose, including fees.
+	MaxOutflow map[solana.PublicKey]uint64
+	// Unbounded lists signers passed as writable to instructions whose
+	// outflow cannot be bounded.
+	Unbounded map[solana.PublicKey]bool
+
+	rent Rent
+}
+
+// Inspect decodes every instruction of tx without signing or sending it.
+// Account creations are charged rent from rent, a signer paying for a size
+// missing from it is unbounded.
+func Inspect(tx *solana.Transaction, rent Rent) (*Report, error) {
+	report := &Report{
+		Signers:    tx.Message.Signers(),
+		MaxOutflow: make(map[solana.PublicKey]uint64),
+		Unbounded:  make(map[solana.PublicKey]bool),
+		rent:       rent,
+	}
+
+	programIDs, err := tx.GetProgramIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve program ids: %w", err)
+	}
+	report.Programs = uniqueKeys(programIDs)
+
+	var (
+		unitLimit    uint64
+		unitPrice    uint64
+		computeUnits uint64
+	)
+	for i, compiled := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(compiled.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+
+		ix := Instruction{Index: i, Program: programID}
+		switch {
+		case programID.Equals(computebudget.ProgramID):
+			ix.Name, ix.Summary = "ComputeBudget", "unknown"
+			decoded, err := computebudget.DecodeInstruction(accounts, compiled.Data)
+			if err != nil {
+				break
+			}
+			ix.Summary = computebudget.InstructionIDToName(decoded.TypeID.Uint8())
+			switch impl := decoded.Impl.(type) {
+			case *computebudget.SetComputeUnitLimit:
+				unitLimit = uint64(impl.Units)
+				ix.Summary += fmt.Sprintf(" units=%d", impl.Units)
+			case *computebudget.SetComputeUnitPrice:
+				unitPrice = impl.MicroLamports
+				ix.Summary += fmt.Sprintf(" microLamports=%d", impl.MicroLamports)
+			}
+			report.Instructions = append(report.Instructions, ix)
+			continue
+		case programID.Equals(system.ProgramID):
+			ix.Name = "System"
+			ix.Summary = report.system(accounts, compiled.Data)
+		case programID.Equals(token.ProgramID):
+			ix.Name = "Token"
+			ix.Summary = decodeToken(accounts, compiled.Data)
+		case programID.Equals(associatedtokenaccount.ProgramID):
+			ix.Name = "AssociatedTokenAccount"
+			ix.Summary = report.associatedToken(accounts, compiled.Data)
+		case programID.Equals(solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)):
+			ix.Name = "PumpFun"
+			ix.Summary = report.pumpFun(accounts, compiled.Data)
+		default:
+			ix.Name = "Unknown"
+			ix.Summary = fmt.Sprintf("%d bytes of data, %d accounts", len(compiled.Data), len(accounts))
+			for _, account := range accounts {
+				if account.IsSigner && account.IsWritable {
+					report.Unbounded[account.PublicKey] = true
+				}
+			}
+		}
+		computeUnits += defaultComputeUnits
+		report.Instructions = append(report.Instructions, ix)
+	}
+
+	// Base fee per signature plus the priority fee, both paid by the fee payer.
+	if unitLimit == 0 {
+		unitLimit = computeUnits
+	}
+	if unitLimit > maxComputeUnits {
+		unitLimit = maxComputeUnits
+	}
+	fee := uint64(len(report.Signers))*internal.LAMPORTS_PER_SIGNATURE + (unitPrice*unitLimit+999_999)/1_000_000
+	if len(report.Signers) > 0 {
+		report.MaxOutflow[report.Signers[0]] += fee
+	}
+
+	return report, nil
+}
+
+func (r *Report) addOutflow(account *solana.AccountMeta, lamports uint64) {
+	if account.IsSigner {
+		r.MaxOutflow[account.PublicKey] += lamports
+	}
+}
+
+// addRent charges account the rent for accounts of sizes.
+func (r *Report) addRent(account *solana.AccountMeta, sizes ...uint64) {
+	for _, size := range sizes {
+		lamports, ok := r.rent[size]
+		if !ok {
+			if account.IsSigner && account.IsWritable {
+				r.Unbounded[account.PublicKey] = true
+			}
+			continue
+		}
+		r.addOutflow(account, lamports)
+	}
+}
+
+func (r *Report) system(accounts []*solana.AccountMeta, data []byte) string {
+	decoded, err := system.DecodeInstruction(accounts, data)
+	if err != nil {
+		for _, account := range accounts {
+			if account.IsSigner && account.IsWritable {
+				r.Unbounded[account.PublicKey] = true
+			}
+		}
+		return "undecodable"
+	}
+
+	name := system.InstructionIDToName(decoded.TypeID.Uint32())
+	switch impl := decoded.Impl.(type) {
+	case *system.Transfer:
+		r.addOutflow(impl.GetFundingAccount(), *impl.Lamports)
+		return fmt.Sprintf("%s %s -> %s %d lamports", name, impl.GetFundingAccount().PublicKey, impl.GetRecipientAccount().PublicKey, *impl.Lamports)
+	case *system.CreateAccount:
+		r.addOutflow(impl.GetFundingAccount(), *impl.Lamports)
+		return fmt.Sprintf("%s %s funded by %s with %d lamports, %d bytes, owner %s", name, impl.GetNewAccount().PublicKey, impl.GetFundingAccount().PublicKey, *impl.Lamports, *impl.Space, impl.Owner)
+	case *system.AdvanceNonceAccount:
+		return fmt.Sprintf("%s %s", name, impl.GetNonceAccount().PublicKey)
+	default:
+		// Anything else that could move lamports out of a signer is not
+		// modelled, so treat it as unbounded.
+		for _, account := range accounts {
+			if account.IsSigner && account.IsWritable {
+				r.Unbounded[account.PublicKey] = true
+			}
+		}
+		return name
+	}
+}
+
+func decodeToken(accounts []*solana.AccountMeta, data []byte) string {
+	decoded, err := token.DecodeInstruction(accounts, data)
+	if err != nil {
+		return "undecodable"
+	}
+
+	name := token.InstructionIDToName(decoded.TypeID.Uint8())
+	switch impl := decoded.Impl.(type) {
+	case *token.Transfer:
+		return fmt.Sprintf("%s %d from %s to %s", name, *impl.Amount, impl.GetSourceAccount().PublicKey, impl.GetDestinationAccount().PublicKey)
+	case *token.TransferChecked:
+		return fmt.Sprintf("%s %d from %s to %s", name, *impl.Amount, impl.GetSourceAccount().PublicKey, impl.GetDestinationAccount().PublicKey)
+	case *token.CloseAccount:
+		return fmt.Sprintf("%s %s, rent to %s", name, impl.GetAccount().PublicKey, impl.GetDestinationAccount().PublicKey)
+	default:
+		return name
+	}
+}
+
+func (r *Report) associatedToken(accounts []*solana.AccountMeta, data []byte) string {
+	if len(accounts) < 4 {
+		return "undecodable"
+	}
+
+	name := "Create"
+	if len(data) > 0 && data[0] == 1 {
+		name = "CreateIdempotent"
+	}
+	r.addRent(accounts[0], internal.TOKEN_ACCOUNT_SIZE)
+	return fmt.Sprintf("%s %s for wallet %s mint %s", name, accounts[1].PublicKey, accounts[2].PublicKey, accounts[3].PublicKey)
+}
+
+func (r *Report) pumpFun(accounts []*solana.AccountMeta, data []byte) string {
+	if len(data) < 8 {
+		return "undecodable"
+	}
+
+	var disc [8]byte
+	copy(disc[:], data[:8])
+	switch {
+	case disc == programs.CreateDiscriminator && len(accounts) >= 8:
+		var args types.CreateData
+		if err := borsh.Deserialize(&args, data[8:]); err != nil {
+			return "create (undecodable args)"
+		}
+		// The user pays rent for the mint, bonding curve, its token account
+		// and the metadata account.
+		r.addRent(accounts[7], internal.MINT_ACCOUNT_SIZE, internal.BONDING_CURVE_SIZE,
+			internal.TOKEN_ACCOUNT_SIZE, internal.METADATA_ACCOUNT_SIZE)
+		return fmt.Sprintf("create mint %s name %q symbol %q uri %s creator %s", accounts[0].PublicKey, args.Name, args.Symbol, args.Uri, args.Creator)
+	case disc == programs.BuyDiscriminator && len(accounts) >= 7:
+		var args types.BuyData
+		if err := borsh.Deserialize(&args, data[8:]); err != nil {
+			return "buy (undecodable args)"
+		}
+		r.addOutflow(accounts[6], args.MaxSolCost+args.MaxSolCost*maxTradeFeeBasisPoints/10000)
+		return fmt.Sprintf("buy %d tokens of %s for at most %d lamports", args.Amount, accounts[2].PublicKey, args.MaxSolCost)
+	case disc == programs.SellDiscriminator && len(accounts) >= 7:
+		var args types.SellData
+		if err := borsh.Deserialize(&args, data[8:]); err != nil {
+			return "sell (undecodable args)"
+		}
+		return fmt.Sprintf("sell %d tokens of %s for at least %d lamports", args.Amount, accounts[2].PublicKey, args.MinSolOutput)
+	default:
+		for _, account := range accounts {
+			if account.IsSigner && account.IsWritable {
+				r.Unbounded[account.PublicKey] = true
+			}
+		}
+		return fmt.Sprintf("unknown instruction %x", data[:8])
+	}
+}
+
+func uniqueKeys(keys []solana.PublicKey) []solana.PublicKey {
+	seen := make(map[solana.PublicKey]bool)
+	var out []solana.PublicKey
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, ix := range r.Instructions {
+		fmt.Fprintf(&b, "#%d %s: %s\n", ix.Index, ix.Name, ix.Summary)
+	}
+	for _, signer := range r.Signers {
+		if r.Unbounded[signer] {
+			fmt.Fprintf(&b, "signer %s: max outflow unbounded\n", signer)
+			continue
+		}
+		fmt.Fprintf(&b, "signer %s: max outflow %d lamports (%.6f SOL)\n", signer, r.MaxOutflow[signer], float64(r.MaxOutflow[signer])/1e9)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
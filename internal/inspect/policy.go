@@ -0,0 +1,101 @@
+package inspect
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"pf-launcher/internal"
+
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+)
+
+// Policy is the firewall applied to a transaction before it is signed.
+type Policy struct {
+	AllowedPrograms []solana.PublicKey
+	// MaxOutflow caps the lamports any signer may lose. Zero disables the cap.
+	MaxOutflow uint64
+}
+
+// DefaultPolicy allows only the programs the launcher itself uses.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		AllowedPrograms: []solana.PublicKey{
+			solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM),
+			solana.SystemProgramID,
+			solana.TokenProgramID,
+			solana.ComputeBudget,
+			associatedtokenaccount.ProgramID,
+		},
+	}
+}
+
+// PolicyFromEnv reads PROGRAM_ALLOWLIST, a comma separated list of program
+// ids that replaces the default allowlist, and MAX_SIGNER_OUTFLOW_SOL.
+func PolicyFromEnv() (*Policy, error) {
+	policy := DefaultPolicy()
+
+	if allowlist := os.Getenv("PROGRAM_ALLOWLIST"); allowlist != "" {
+		policy.AllowedPrograms = nil
+		for _, entry := range strings.Split(allowlist, ",") {
+			programID, err := solana.PublicKeyFromBase58(strings.TrimSpace(entry))
+			if err != nil {
+				return nil, fmt.Errorf("invalid program id %q in PROGRAM_ALLOWLIST: %w", entry, err)
+			}
+			policy.AllowedPrograms = append(policy.AllowedPrograms, programID)
+		}
+	}
+
+	if maxOutflow := os.Getenv("MAX_SIGNER_OUTFLOW_SOL"); maxOutflow != "" {
+		var sol float64
+		if _, err := fmt.Sscanf(maxOutflow, "%g", &sol); err != nil {
+			return nil, fmt.Errorf("invalid MAX_SIGNER_OUTFLOW_SOL %q: %w", maxOutflow, err)
+		}
+		policy.MaxOutflow = uint64(sol * 1e9)
+	}
+
+	return policy, nil
+}
+
+// Check refuses transactions that call programs outside the allowlist or that
+// can take more than MaxOutflow from a signer.
+func (p *Policy) Check(r *Report) error {
+	for _, programID := range r.Programs {
+		if !p.allowed(programID) {
+			return fmt.Errorf("program %s is not on the allowlist", programID)
+		}
+	}
+
+	if p.MaxOutflow == 0 {
+		return nil
+	}
+	for _, signer := range r.Signers {
+		if r.Unbounded[signer] {
+			return fmt.Errorf("outflow from signer %s cannot be bounded", signer)
+		}
+		if r.MaxOutflow[signer] > p.MaxOutflow {
+			return fmt.Errorf("signer %s can lose %d lamports, over the %d limit", signer, r.MaxOutflow[signer], p.MaxOutflow)
+		}
+	}
+	return nil
+}
+
+func (p *Policy) allowed(programID solana.PublicKey) bool {
+	for _, allowed := range p.AllowedPrograms {
+		if allowed.Equals(programID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Guard inspects tx and checks it against p, returning the report either way
+// so callers can show what was refused.
+func (p *Policy) Guard(tx *solana.Transaction, rent Rent) (*Report, error) {
+	report, err := Inspect(tx, rent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect transaction: %w", err)
+	}
+	return report, p.Check(report)
+}
@@ -0,0 +1,156 @@
+package ipfs
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// The layout Pinata and Kubo use for CIDv1 uploads: 256 KiB raw leaves under
+// a balanced tree of dag-pb UnixFS nodes with at most 174 links each.
+const (
+	ChunkSize = 256 << 10
+	MaxLinks  = 174
+
+	codecRaw   = 0x55
+	codecDagPB = 0x70
+	sha2_256   = 0x12
+)
+
+var base32Lower = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// node is a block in the file's DAG.
+type node struct {
+	cid []byte
+	// size is the block plus all blocks below it, the Tsize of a link.
+	size uint64
+	// fileSize is the file content under the node.
+	fileSize uint64
+}
+
+// Hasher computes the CID a file gets when added with CIDv1, without
+// keeping more than one chunk in memory.
+type Hasher struct {
+	buf    []byte
+	leaves []node
+}
+
+func NewHasher() *Hasher {
+	return &Hasher{buf: make([]byte, 0, ChunkSize)}
+}
+
+func (h *Hasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		take := min(ChunkSize-len(h.buf), len(p))
+		h.buf = append(h.buf, p[:take]...)
+		p = p[take:]
+		if len(h.buf) == ChunkSize {
+			h.flush()
+		}
+	}
+	return n, nil
+}
+
+func (h *Hasher) flush() {
+	h.leaves = append(h.leaves, node{cid: newCID(codecRaw, h.buf), size: uint64(len(h.buf)), fileSize: uint64(len(h.buf))})
+	h.buf = h.buf[:0]
+}
+
+// Sum returns the CID of everything written, in base32.
+func (h *Hasher) Sum() string {
+	leaves := h.leaves
+	if len(h.buf) > 0 || len(leaves) == 0 {
+		leaves = append(leaves, node{cid: newCID(codecRaw, h.buf), size: uint64(len(h.buf)), fileSize: uint64(len(h.buf))})
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		var parents []node
+		for i := 0; i < len(level); i += MaxLinks {
+			parents = append(parents, fileNode(level[i:min(i+MaxLinks, len(level))]))
+		}
+		level = parents
+	}
+	return encodeCID(level[0].cid)
+}
+
+// CID returns the CID data gets when added with CIDv1.
+func CID(data []byte) string {
+	h := NewHasher()
+	h.Write(data)
+	return h.Sum()
+}
+
+// Equal reports whether two CID strings name the same content, ignoring
+// case, which base32 does not distinguish.
+func Equal(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// Verify checks that the CID a service returned for content is the one
+// computed locally.
+func Verify(expected, returned string) error {
+	if !Equal(expected, returned) {
+		return fmt.Errorf("content hashes to %s but %s was returned, the upload was altered or misrouted", expected, returned)
+	}
+	return nil
+}
+
+// fileNode builds the dag-pb UnixFS file node linking children.
+func fileNode(children []node) node {
+	var data []byte
+	data = appendField(data, 1, 2) // Type: File
+	var fileSize uint64
+	for _, child := range children {
+		fileSize += child.fileSize
+	}
+	data = appendField(data, 3, fileSize)
+	for _, child := range children {
+		data = appendField(data, 4, child.fileSize) // blocksizes
+	}
+
+	// dag-pb puts the links before the data.
+	var block []byte
+	size := uint64(0)
+	for _, child := range children {
+		var link []byte
+		link = appendBytes(link, 1, child.cid)
+		link = appendBytes(link, 2, nil) // Name, empty but present
+		link = appendField(link, 3, child.size)
+		block = appendBytes(block, 2, link)
+		size += child.size
+	}
+	block = appendBytes(block, 1, data)
+
+	return node{cid: newCID(codecDagPB, block), size: size + uint64(len(block)), fileSize: fileSize}
+}
+
+// appendField appends a protobuf varint field.
+func appendField(b []byte, field int, value uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3))
+	return binary.AppendUvarint(b, value)
+}
+
+// appendBytes appends a protobuf length-delimited field.
+func appendBytes(b []byte, field int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|2))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// newCID is the binary CIDv1 of block with a sha2-256 multihash.
+func newCID(codec uint64, block []byte) []byte {
+	sum := sha256.Sum256(block)
+	cid := binary.AppendUvarint(nil, 1)
+	cid = binary.AppendUvarint(cid, codec)
+	cid = append(cid, sha2_256, sha256.Size)
+	return append(cid, sum[:]...)
+}
+
+// encodeCID is the multibase base32 form, the "b..." string gateways use.
+func encodeCID(cid []byte) string {
+	return "b" + base32Lower.EncodeToString(cid)
+}
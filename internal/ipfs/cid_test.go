@@ -0,0 +1,88 @@
+package ipfs
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// pattern is n bytes of a sequence that does not repeat on a chunk boundary.
+func pattern(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+var cidTests = []struct {
+	name string
+	data []byte
+	want string
+}{
+	{"empty", nil, "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+	{"hello world", []byte("hello world"), "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e"},
+	{"one chunk", pattern(1000), "bafkreicojquuwmy7piqjti3zx3buxh47ya64i2vumxmzr5gwqpnfgsd6nu"},
+	{"exactly one chunk", pattern(ChunkSize), "bafkreibruh455iawsviqslif5c7uurdcfdemh22mtnytyzvnzn75kpejxy"},
+	{"one chunk and a byte", pattern(ChunkSize + 1), "bafybeiexg2oqkfnj56l7fcmawswqbijt5shq4b5rg6a546uwpkqqzwjioi"},
+	{"several chunks", pattern(3*ChunkSize + 100), "bafybeidgbfvpggtre34rfal7xfzx33nqt3mdwa6kot6iab5go3kvdc3kl4"},
+	{"full node", pattern(MaxLinks * ChunkSize), "bafybeihpe5snhzneq7xs53nivmsopto5lrogo3wjynauqylqeym5a3irbm"},
+	{"second layer", pattern((MaxLinks+1)*ChunkSize + 1), "bafybeihzhgkxa5ea4r5cr73r73tsf3ppwpstcxnsuyqxio3vy7xpikkhmi"},
+}
+
+func TestCID(t *testing.T) {
+	for _, tt := range cidTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CID(tt.data); got != tt.want {
+				t.Errorf("CID = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHasherWrites checks the CID does not depend on how writes are split
+// across chunk boundaries.
+func TestHasherWrites(t *testing.T) {
+	data := pattern(3*ChunkSize + 100)
+	h := NewHasher()
+	for rest := data; len(rest) > 0; {
+		n := min(len(rest), 100_003)
+		h.Write(rest[:n])
+		rest = rest[n:]
+	}
+	if got, want := h.Sum(), CID(data); got != want {
+		t.Errorf("split writes = %s, one write = %s", got, want)
+	}
+}
+
+// TestCIDMatchesKubo checks the vectors against a local Kubo when one is
+// installed.
+func TestCIDMatchesKubo(t *testing.T) {
+	if _, err := exec.LookPath("ipfs"); err != nil {
+		t.Skip("ipfs not installed")
+	}
+	for _, tt := range cidTests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command("ipfs", "add", "--only-hash", "--cid-version=1", "-Q")
+			cmd.Stdin = bytes.NewReader(tt.data)
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("ipfs add: %v", err)
+			}
+			if got := strings.TrimSpace(string(out)); got != tt.want {
+				t.Errorf("kubo = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	cid := "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e"
+	if !Equal(cid, strings.ToUpper(cid)) {
+		t.Error("Equal is case sensitive")
+	}
+	if Equal(cid, "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku") {
+		t.Error("Equal matched different CIDs")
+	}
+}
@@ -0,0 +1,216 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stages a launch moves through, in order.
+const (
+	StageStarted          = "started"
+	StageImageUploaded    = "image_uploaded"
+	StageMetadataUploaded = "metadata_uploaded"
+	StageSigned           = "signed"
+	StageSent             = "sent"
+	StageConfirmed        = "confirmed"
+	// StageFailed means the launch never went out, or its transaction
+	// failed or expired. The uploads can still be reused.
+	StageFailed = "failed"
+)
+
+// Record is one line of the journal. Empty fields keep the value of earlier
+// records for the same launch.
+type Record struct {
+	ID                   string    `json:"id"`
+	Key                  string    `json:"key,omitempty"`
+	Stage                string    `json:"stage"`
+	Time                 time.Time `json:"time"`
+	Name                 string    `json:"name,omitempty"`
+	Symbol               string    `json:"symbol,omitempty"`
+	ImageCID             string    `json:"imageCid,omitempty"`
+	MetadataURI          string    `json:"metadataUri,omitempty"`
+	Mint                 string    `json:"mint,omitempty"`
+	Signature            string    `json:"signature,omitempty"`
+	LastValidBlockHeight uint64    `json:"lastValidBlockHeight,omitempty"`
+	Error                string    `json:"error,omitempty"`
+}
+
+// Entry is the current state of a launch, folded from its records.
+type Entry struct {
+	Record
+	Started time.Time
+}
+
+// Pending reports whether the launch has a signed transaction whose outcome
+// is not known yet.
+func (e *Entry) Pending() bool {
+	return e.Signature != "" && (e.Stage == StageSigned || e.Stage == StageSent)
+}
+
+func (e *Entry) String() string {
+	line := fmt.Sprintf("%s  %-17s  %s (%s)  %s", e.ID, e.Stage, e.Name, e.Symbol, e.Time.Local().Format(time.DateTime))
+	if e.MetadataURI != "" {
+		line += "  " + e.MetadataURI
+	}
+	if e.Mint != "" {
+		line += "  mint " + e.Mint
+	}
+	if e.Signature != "" {
+		line += "  sig " + e.Signature
+	}
+	if e.Error != "" {
+		line += "  error: " + e.Error
+	}
+	return line
+}
+
+// Journal is an append-only file of launch records. Every record is synced
+// to disk before Record returns, so a crash loses at most the step that was
+// in flight.
+type Journal struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string]*Entry
+	order   []string
+}
+
+func Open(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	j := &Journal{file: file, entries: make(map[string]*Entry)}
+	reader := bufio.NewReader(file)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A torn last line from a crash is dropped so new records start
+			// on a line of their own.
+			if len(data) > 0 {
+				if err := file.Truncate(offset); err != nil {
+					file.Close()
+					return nil, fmt.Errorf("failed to drop torn journal record: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("journal %s line %d: %w", path, line, err)
+		}
+		j.apply(r)
+		offset += int64(len(data))
+	}
+	return j, nil
+}
+
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// Start records a new launch and returns its id.
+func (j *Journal) Start(key, name, symbol string) (string, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate journal id: %w", err)
+	}
+	r := Record{ID: hex.EncodeToString(id), Key: key, Stage: StageStarted, Name: name, Symbol: symbol}
+	return r.ID, j.Record(r)
+}
+
+// Record appends r and syncs it.
+func (j *Journal) Record(r Record) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal record: %w", err)
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	j.apply(r)
+	return nil
+}
+
+func (j *Journal) apply(r Record) {
+	e, ok := j.entries[r.ID]
+	if !ok {
+		e = &Entry{Record: r, Started: r.Time}
+		j.entries[r.ID] = e
+		j.order = append(j.order, r.ID)
+		return
+	}
+
+	e.Stage, e.Time, e.Error = r.Stage, r.Time, r.Error
+	update := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	update(&e.Key, r.Key)
+	update(&e.Name, r.Name)
+	update(&e.Symbol, r.Symbol)
+	update(&e.ImageCID, r.ImageCID)
+	update(&e.MetadataURI, r.MetadataURI)
+	update(&e.Mint, r.Mint)
+	update(&e.Signature, r.Signature)
+	if r.LastValidBlockHeight != 0 {
+		e.LastValidBlockHeight = r.LastValidBlockHeight
+	}
+}
+
+// Entries returns every launch, oldest first.
+func (j *Journal) Entries() []*Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]*Entry, 0, len(j.order))
+	for _, id := range j.order {
+		copied := *j.entries[id]
+		entries = append(entries, &copied)
+	}
+	return entries
+}
+
+// Latest returns the most recent launch with key, or nil.
+func (j *Journal) Latest(key string) *Entry {
+	entries := j.Entries()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Key == key {
+			return entries[i]
+		}
+	}
+	return nil
+}
+
+// Key identifies a launch by its parameters, so re-running the same launch
+// finds the earlier attempt.
+func Key(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
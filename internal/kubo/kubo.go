@@ -0,0 +1,175 @@
+package kubo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"pf-launcher/internal/ipfs"
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/storage"
+)
+
+var _ storage.Storage = (*Client)(nil)
+
+// Client stores content on a Kubo (go-ipfs) node through its HTTP RPC API.
+type Client struct {
+	APIURL string
+	// Auth is sent as the Authorization header when the node requires
+	// API authorization, e.g. "Bearer <token>" or "Basic <credentials>".
+	Auth   string
+	Client *http.Client
+}
+
+func NewClient(apiURL, auth string) *Client {
+	if apiURL == "" {
+		apiURL = "http://127.0.0.1:5001"
+	}
+	return &Client{
+		APIURL: strings.TrimSuffix(apiURL, "/"),
+		Auth:   auth,
+		Client: &http.Client{},
+	}
+}
+
+// apiError is the body Kubo returns with a failed call.
+type apiError struct {
+	Message string
+	Code    int
+	Type    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("kubo API error: %s", e.Message)
+}
+
+func (c *Client) Put(ctx context.Context, name string, r io.Reader) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	hasher := ipfs.NewHasher()
+	if _, err := io.Copy(part, io.TeeReader(r, hasher)); err != nil {
+		return "", fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	// CIDv1, as Pinata is asked for.
+	params := url.Values{"cid-version": {"1"}, "pin": {"true"}}
+	var result struct {
+		Name string
+		Hash string
+		Size string
+	}
+	if err := c.call(ctx, "add", params, body.Bytes(), writer.FormDataContentType(), &result); err != nil {
+		return "", err
+	}
+	if err := ipfs.Verify(hasher.Sum(), result.Hash); err != nil {
+		return "", fmt.Errorf("kubo add of %s: %w", name, err)
+	}
+	return result.Hash, nil
+}
+
+func (c *Client) URI(cid string) string {
+	return "ipfs://" + cid
+}
+
+func (c *Client) Pin(ctx context.Context, cid string) error {
+	return c.call(ctx, "pin/add", url.Values{"arg": {cid}}, nil, "", nil)
+}
+
+func (c *Client) Unpin(ctx context.Context, cid string) error {
+	err := c.call(ctx, "pin/rm", url.Values{"arg": {cid}}, nil, "", nil)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "not pinned") {
+		return fmt.Errorf("%s: %w", cid, storage.ErrNotFound)
+	}
+	return err
+}
+
+// Stat only looks at blocks the node already has, so content that is
+// neither pinned nor cached is reported as not found instead of fetched.
+func (c *Client) Stat(ctx context.Context, cid string) (*storage.Object, error) {
+	var stat struct {
+		Hash           string
+		CumulativeSize int64
+	}
+	params := url.Values{"arg": {"/ipfs/" + cid}, "offline": {"true"}}
+	if err := c.call(ctx, "files/stat", params, nil, "", &stat); err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && (strings.Contains(apiErr.Message, "not found") || strings.Contains(apiErr.Message, "offline")) {
+			return nil, fmt.Errorf("%s: %w", cid, storage.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	var pins struct {
+		Keys map[string]struct{ Type string }
+	}
+	err := c.call(ctx, "pin/ls", url.Values{"arg": {cid}, "type": {"recursive"}}, nil, "", &pins)
+	var apiErr *apiError
+	if err != nil && !(errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "not pinned")) {
+		return nil, err
+	}
+	return &storage.Object{CID: stat.Hash, Size: stat.CumulativeSize, Pinned: err == nil && len(pins.Keys) > 0}, nil
+}
+
+// call POSTs to /api/v0/<command>, as every Kubo RPC call is a POST, and
+// decodes the JSON response into out when it is not nil.
+func (c *Client) call(ctx context.Context, command string, params url.Values, body []byte, contentType string, out any) error {
+	endpoint := c.APIURL + "/api/v0/" + command + "?" + params.Encode()
+	resp, err := retry.Value(ctx, retry.Default, "kubo "+command, func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if contentType != "" {
+			req.Header.Add("Content-Type", contentType)
+		}
+		if c.Auth != "" {
+			req.Header.Add("Authorization", c.Auth)
+		}
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		// Kubo answers 500 for command errors, like a CID that is not
+		// pinned, which are not worth retrying.
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
+			return nil, fmt.Errorf("kubo API error: %w", retry.NewHTTPError(resp))
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusInternalServerError {
+		var apiErr apiError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Message == "" {
+			return fmt.Errorf("kubo API error: %s", resp.Status)
+		}
+		return &apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"pf-launcher/internal/fill"
+	"pf-launcher/internal/inspect"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	KindLaunch = "launch"
+	KindBuy    = "buy"
+	KindSell   = "sell"
+)
+
+// Envelope is the on-disk form of a transaction that is built on one machine,
+// signed on another and submitted later.
+type Envelope struct {
+	Kind                 string    `json:"kind"`
+	Transaction          string    `json:"transaction"`
+	Signers              []string  `json:"signers"`
+	Mint                 string    `json:"mint"`
+	Blockhash            string    `json:"blockhash"`
+	LastValidBlockHeight uint64    `json:"lastValidBlockHeight,omitempty"`
+	NonceAccount         string    `json:"nonceAccount,omitempty"`
+	CreatedAt            time.Time `json:"createdAt"`
+	// Quote is the price the trade was built at, checked after submission.
+	Quote *fill.Quote `json:"quote,omitempty"`
+	// Rent is the cluster's rent at build time, so the signing machine can
+	// bound what account creations cost without RPC access.
+	Rent inspect.Rent `json:"rent,omitempty"`
+}
+
+func NewEnvelope(kind string, tx *solana.Transaction, mint solana.PublicKey) (*Envelope, error) {
+	env := &Envelope{
+		Kind:      kind,
+		Mint:      mint.String(),
+		Blockhash: tx.Message.RecentBlockhash.String(),
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, signer := range tx.Message.Signers() {
+		env.Signers = append(env.Signers, signer.String())
+	}
+	if err := env.SetTransaction(tx); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func (e *Envelope) SetTransaction(tx *solana.Transaction) error {
+	encoded, err := tx.ToBase64()
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction: %w", err)
+	}
+	e.Transaction = encoded
+	return nil
+}
+
+func (e *Envelope) Tx() (*solana.Transaction, error) {
+	tx, err := solana.TransactionFromBase64(e.Transaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// MissingSigners returns the required signers that have not signed yet.
+func (e *Envelope) MissingSigners() ([]solana.PublicKey, error) {
+	tx, err := e.Tx()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []solana.PublicKey
+	for i, signer := range tx.Message.Signers() {
+		if i >= len(tx.Signatures) || tx.Signatures[i].IsZero() {
+			missing = append(missing, signer)
+		}
+	}
+	return missing, nil
+}
+
+// Sign adds signatures for every required signer found in keys. Signers
+// without a matching key are left untouched.
+func (e *Envelope) Sign(keys ...solana.PrivateKey) (int, error) {
+	tx, err := e.Tx()
+	if err != nil {
+		return 0, err
+	}
+
+	signed := 0
+	_, err = tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+		for i := range keys {
+			if keys[i].PublicKey().Equals(key) {
+				signed++
+				return &keys[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	return signed, e.SetTransaction(tx)
+}
+
+func Save(path string, env *Envelope) error {
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write envelope: %w", err)
+	}
+	return nil
+}
+
+func Load(path string) (*Envelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read envelope: %w", err)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+	}
+	return &env, nil
+}
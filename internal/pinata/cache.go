@@ -0,0 +1,124 @@
+package pinata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// CacheEntry is content that was uploaded before.
+type CacheEntry struct {
+	CID      string    `json:"cid"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Uploaded time.Time `json:"uploaded"`
+}
+
+// Cache maps the SHA-256 of uploaded content to its CID, so identical
+// content is not uploaded twice. It is saved to a JSON file after every
+// change.
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]CacheEntry
+}
+
+func OpenCache(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: make(map[string]CacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse upload cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// contentKey hashes r to the key its content is cached under, returning the
+// content size too.
+func contentKey(r io.Reader) (string, int64, error) {
+	w := newKeyWriter()
+	if _, err := io.Copy(w, r); err != nil {
+		return "", 0, err
+	}
+	return w.key(), w.size, nil
+}
+
+// keyWriter computes the key of the content written to it, for content that
+// is hashed while it streams.
+type keyWriter struct {
+	hash hash.Hash
+	size int64
+}
+
+func newKeyWriter() *keyWriter {
+	return &keyWriter{hash: sha256.New()}
+}
+
+func (w *keyWriter) Write(p []byte) (int, error) {
+	w.size += int64(len(p))
+	return w.hash.Write(p)
+}
+
+func (w *keyWriter) key() string {
+	return hex.EncodeToString(w.hash.Sum(nil))
+}
+
+func (c *Cache) get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+func (c *Cache) put(key string, entry CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	return c.save()
+}
+
+func (c *Cache) remove(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return c.save()
+}
+
+// forget removes every entry for cid.
+func (c *Cache) forget(cid string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if entry.CID == cid {
+			delete(c.entries, key)
+		}
+	}
+	return c.save()
+}
+
+func (c *Cache) save() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal upload cache: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write upload cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write upload cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package pinata
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"strconv"
+
+	"pf-launcher/internal/storage"
+)
+
+// Keyvalues the launcher puts on its uploads.
+const (
+	KeyLaunch = "launch"
+	KeyMint   = "mint"
+)
+
+// FileFilter narrows ListFiles. Empty fields match everything.
+type FileFilter struct {
+	Name     string
+	CID      string
+	MimeType string
+	GroupID  string
+	// Keyvalues must all be present on a file with these values.
+	Keyvalues map[string]string
+	Limit     int
+	PageToken string
+}
+
+func (f FileFilter) query() url.Values {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("name", f.Name)
+	set("cid", f.CID)
+	set("mimeType", f.MimeType)
+	set("group", f.GroupID)
+	set("pageToken", f.PageToken)
+	for key, value := range f.Keyvalues {
+		q.Set("metadata["+key+"]", value)
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return q
+}
+
+// ListFiles returns one page of files and the token of the next page, empty
+// on the last one.
+func (c *PinataClient) ListFiles(ctx context.Context, filter FileFilter) ([]File, string, error) {
+	var result struct {
+		Data struct {
+			Files         []File `json:"files"`
+			NextPageToken string `json:"next_page_token"`
+		} `json:"data"`
+	}
+	if err := c.api(ctx, "GET", "/v3/files/public?"+filter.query().Encode(), nil, &result); err != nil {
+		return nil, "", err
+	}
+	return result.Data.Files, result.Data.NextPageToken, nil
+}
+
+// AllFiles follows the pages of ListFiles, up to filter.Limit files when set.
+func (c *PinataClient) AllFiles(ctx context.Context, filter FileFilter) ([]File, error) {
+	var all []File
+	for {
+		files, next, err := c.ListFiles(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, files...)
+		if filter.Limit > 0 && len(all) >= filter.Limit {
+			return all[:filter.Limit], nil
+		}
+		if next == "" || len(files) == 0 {
+			return all, nil
+		}
+		filter.PageToken = next
+	}
+}
+
+func (c *PinataClient) GetFile(ctx context.Context, id string) (*File, error) {
+	var result struct {
+		Data File `json:"data"`
+	}
+	if err := c.api(ctx, "GET", "/v3/files/public/"+url.PathEscape(id), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// FileByCID returns the newest file with cid.
+func (c *PinataClient) FileByCID(ctx context.Context, cid string) (*File, error) {
+	files, _, err := c.ListFiles(ctx, FileFilter{CID: cid, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: %w", cid, storage.ErrNotFound)
+	}
+	return &files[0], nil
+}
+
+// UpdateFile renames a file when name is not empty and sets keyvalues.
+func (c *PinataClient) UpdateFile(ctx context.Context, id, name string, keyvalues map[string]string) (*File, error) {
+	body := map[string]any{}
+	if name != "" {
+		body["name"] = name
+	}
+	if keyvalues != nil {
+		body["keyvalues"] = keyvalues
+	}
+	var result struct {
+		Data File `json:"data"`
+	}
+	if err := c.api(ctx, "PUT", "/v3/files/public/"+url.PathEscape(id), body, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// DeleteFile deletes the file with id and, with a Cache, forgets its CID so
+// the content is uploaded again instead of reusing the deleted file.
+func (c *PinataClient) DeleteFile(ctx context.Context, id string) error {
+	if c.Cache == nil {
+		return c.deleteFile(ctx, id)
+	}
+	file, err := c.GetFile(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.deleteFile(ctx, id); err != nil {
+		return err
+	}
+	return c.Cache.forget(file.CID)
+}
+
+func (c *PinataClient) deleteFile(ctx context.Context, id string) error {
+	return c.api(ctx, "DELETE", "/v3/files/public/"+url.PathEscape(id), nil, nil)
+}
+
+// Label merges keyvalues into every file with cid and adds them to groupID
+// when it is not empty.
+func (c *PinataClient) Label(ctx context.Context, cid, groupID string, keyvalues map[string]string) error {
+	files, err := c.filesByCID(ctx, cid)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s: %w", cid, storage.ErrNotFound)
+	}
+	for _, file := range files {
+		if len(keyvalues) > 0 {
+			merged := maps.Clone(file.Keyvalues)
+			if merged == nil {
+				merged = map[string]string{}
+			}
+			maps.Copy(merged, keyvalues)
+			if _, err := c.UpdateFile(ctx, file.ID, "", merged); err != nil {
+				return fmt.Errorf("failed to update %s: %w", file.ID, err)
+			}
+		}
+		if groupID != "" && file.GroupID != groupID {
+			if err := c.AddToGroup(ctx, groupID, file.ID); err != nil {
+				return fmt.Errorf("failed to group %s: %w", file.ID, err)
+			}
+		}
+	}
+	return nil
+}
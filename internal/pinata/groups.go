@@ -0,0 +1,75 @@
+package pinata
+
+import (
+	"context"
+	"net/url"
+)
+
+// Group collects files, the launcher uses one per campaign.
+type Group struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ListGroups returns the groups whose name contains name, all when empty.
+func (c *PinataClient) ListGroups(ctx context.Context, name string) ([]Group, error) {
+	var all []Group
+	q := url.Values{}
+	if name != "" {
+		q.Set("name", name)
+	}
+	for {
+		var result struct {
+			Data struct {
+				Groups        []Group `json:"groups"`
+				NextPageToken string  `json:"next_page_token"`
+			} `json:"data"`
+		}
+		if err := c.api(ctx, "GET", "/v3/groups/public?"+q.Encode(), nil, &result); err != nil {
+			return nil, err
+		}
+		all = append(all, result.Data.Groups...)
+		if result.Data.NextPageToken == "" || len(result.Data.Groups) == 0 {
+			return all, nil
+		}
+		q.Set("pageToken", result.Data.NextPageToken)
+	}
+}
+
+func (c *PinataClient) CreateGroup(ctx context.Context, name string) (*Group, error) {
+	var result struct {
+		Data Group `json:"data"`
+	}
+	if err := c.api(ctx, "POST", "/v3/groups/public", map[string]string{"name": name}, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// EnsureGroup returns the group called name, creating it if there is none.
+func (c *PinataClient) EnsureGroup(ctx context.Context, name string) (*Group, error) {
+	groups, err := c.ListGroups(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return &group, nil
+		}
+	}
+	return c.CreateGroup(ctx, name)
+}
+
+// DeleteGroup deletes the group, not the files in it.
+func (c *PinataClient) DeleteGroup(ctx context.Context, id string) error {
+	return c.api(ctx, "DELETE", "/v3/groups/public/"+url.PathEscape(id), nil, nil)
+}
+
+func (c *PinataClient) AddToGroup(ctx context.Context, groupID, fileID string) error {
+	return c.api(ctx, "PUT", "/v3/groups/public/"+url.PathEscape(groupID)+"/ids/"+url.PathEscape(fileID), nil, nil)
+}
+
+func (c *PinataClient) RemoveFromGroup(ctx context.Context, groupID, fileID string) error {
+	return c.api(ctx, "DELETE", "/v3/groups/public/"+url.PathEscape(groupID)+"/ids/"+url.PathEscape(fileID), nil, nil)
+}
@@ -2,93 +2,226 @@ package pinata
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"pf-launcher/internal/ipfs"
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/storage"
 )
 
 type PinataClient struct {
 	JwtSecret string
 	BaseURL   string
+	APIURL    string
 	Client    *http.Client
+
+	// Cache, when set, skips uploading content that was uploaded before.
+	// With VerifyPins a cached CID is only used once Pinata confirms it is
+	// still pinned.
+	Cache      *Cache
+	VerifyPins bool
 }
 
 func NewClient(jwtSecret string) *PinataClient {
 	return &PinataClient{
 		JwtSecret: jwtSecret,
 		BaseURL:   "https://uploads.pinata.cloud",
+		APIURL:    "https://api.pinata.cloud",
 		Client:    &http.Client{},
 	}
 }
 
-func (c *PinataClient) UploadFile(filePath string) (string, error) {
+// Upload describes content streamed to Pinata.
+type Upload struct {
+	Name string
+	// ContentType defaults to the type of the Name extension.
+	ContentType string
+	// Size is the total passed to Progress, 0 when unknown.
+	Size int64
+	// GroupID and Keyvalues are set on the new file. A cached upload keeps
+	// what it was first uploaded with, use Label to change it.
+	GroupID   string
+	Keyvalues map[string]string
+	// Progress, when set, is called from the upload goroutine with the bytes
+	// sent so far.
+	Progress func(sent, total int64)
+}
+
+func (u *Upload) setContentType() {
+	if u.ContentType == "" {
+		u.ContentType = mime.TypeByExtension(filepath.Ext(u.Name))
+	}
+	if u.ContentType == "" {
+		u.ContentType = "application/octet-stream"
+	}
+}
+
+func (c *PinataClient) UploadFile(ctx context.Context, filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	return c.UploadReader(ctx, file, Upload{Name: filepath.Base(filePath), Size: info.Size()})
+}
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// UploadBytes uploads data as a file called name.
+func (c *PinataClient) UploadBytes(ctx context.Context, name string, data []byte) (string, error) {
+	return c.UploadReader(ctx, bytes.NewReader(data), Upload{Name: name, Size: int64(len(data))})
+}
 
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+func (c *PinataClient) UploadJSON(ctx context.Context, data interface{}) (string, error) {
+	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return "", fmt.Errorf("failed to create form file: %w", err)
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return "", fmt.Errorf("failed to copy file: %w", err)
+	return c.UploadReader(ctx, bytes.NewReader(jsonData), Upload{Name: "metadata.json", ContentType: "application/json", Size: int64(len(jsonData))})
+}
+
+// UploadReader streams r to Pinata without buffering it. A failed upload is
+// only retried when r is an io.Seeker that can be rewound. With a Cache an
+// io.ReadSeeker is read once to hash it first; anything else can't be looked
+// up without reading it twice, so it is hashed as it streams and only cached
+// for later uploads.
+func (c *PinataClient) UploadReader(ctx context.Context, r io.Reader, u Upload) (string, error) {
+	u.setContentType()
+	if c.Cache == nil {
+		return c.send(ctx, r, u)
 	}
 
-	// Add pinataOptions for public network
-	options := map[string]interface{}{
-		"cidVersion":        1,
-		"wrapWithDirectory": false,
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		key := newKeyWriter()
+		cid, err := c.send(ctx, io.TeeReader(r, key), u)
+		if err != nil {
+			return "", err
+		}
+		return cid, c.remember(key.key(), cid, u.Name, key.size)
 	}
-	optionsJSON, err := json.Marshal(options)
+	start, err := rs.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal options: %w", err)
+		return "", fmt.Errorf("failed to seek %s: %w", u.Name, err)
 	}
-
-	err = writer.WriteField("pinataOptions", string(optionsJSON))
+	key, size, err := contentKey(rs)
 	if err != nil {
-		return "", fmt.Errorf("failed to write pinataOptions: %w", err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind %s: %w", u.Name, err)
 	}
 
-	// Add network: public to the form
-	err = writer.WriteField("network", "public")
-	if err != nil {
-		return "", fmt.Errorf("failed to write network field: %w", err)
+	if entry, ok := c.Cache.get(key); ok {
+		if !c.VerifyPins {
+			return entry.CID, nil
+		}
+		_, err := c.Stat(ctx, entry.CID)
+		if err == nil {
+			return entry.CID, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return "", fmt.Errorf("failed to check pin of cached %s: %w", entry.CID, err)
+		}
+		if err := c.Cache.remove(key); err != nil {
+			return "", err
+		}
 	}
 
-	err = writer.Close()
+	cid, err := c.send(ctx, rs, u)
 	if err != nil {
-		return "", fmt.Errorf("failed to close writer: %w", err)
+		return "", err
 	}
+	return cid, c.remember(key, cid, u.Name, size)
+}
 
-	req, err := http.NewRequest("POST", c.BaseURL+"/v3/files", body)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+func (c *PinataClient) remember(key, cid, name string, size int64) error {
+	return c.Cache.put(key, CacheEntry{CID: cid, Name: name, Size: size, Uploaded: time.Now().UTC()})
+}
+
+func (c *PinataClient) send(ctx context.Context, file io.Reader, u Upload) (string, error) {
+	return post(ctx, c.Client, c.BaseURL+"/v3/files", c.JwtSecret, file, u)
+}
+
+// post uploads file as a multipart form to url, with the JWT when it is not
+// empty, and returns the CID after checking it against the content.
+func post(ctx context.Context, client *http.Client, url, jwt string, file io.Reader, u Upload) (string, error) {
+	policy := retry.Default
+	seeker, seekable := file.(io.Seeker)
+	var start int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return "", fmt.Errorf("failed to seek %s: %w", u.Name, err)
+		}
+	} else {
+		// What was sent is gone, another attempt would upload the rest.
+		policy.MaxAttempts = 1
 	}
 
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.JwtSecret))
+	var hasher *ipfs.Hasher
+	resp, err := retry.Value(ctx, policy, "pinata upload", func(ctx context.Context) (*http.Response, error) {
+		if seekable {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("failed to rewind %s: %w", u.Name, err)
+			}
+		}
+		// The CID is computed while streaming, to check the one Pinata
+		// returns.
+		hasher = ipfs.NewHasher()
+		body, contentType, done := multipartBody(file, u, hasher)
 
-	resp, err := c.Client.Do(req)
+		req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Add("Content-Type", contentType)
+		if jwt != "" {
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+		}
+
+		// A failed attempt stops the form writer and waits for it, so a
+		// retry does not rewind file while it is still being copied.
+		abort := func() {
+			body.Close()
+			<-done
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			abort()
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			httpErr := retry.NewHTTPError(resp)
+			abort()
+			return nil, fmt.Errorf("pinata API error: %w", httpErr)
+		}
+		if err := <-done; err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return resp, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("pinata API error: %s - %s", resp.Status, string(body))
-	}
-
 	var result struct {
 		Data struct {
 			Cid string `json:"cid"`
@@ -98,29 +231,93 @@ func (c *PinataClient) UploadFile(filePath string) (string, error) {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	if err := ipfs.Verify(hasher.Sum(), result.Data.Cid); err != nil {
+		return "", fmt.Errorf("pinata upload of %s: %w", u.Name, err)
+	}
 	return result.Data.Cid, nil
 }
 
-func (c *PinataClient) UploadJSON(data interface{}) (string, error) {
-	jsonData, err := json.Marshal(data)
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartBody streams the upload form through a pipe, copying file into
+// hasher as it is sent. done receives the result once the form is written.
+func multipartBody(file io.Reader, u Upload, hasher io.Writer) (io.ReadCloser, string, <-chan error) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	done := make(chan error, 1)
+	go func() {
+		err := writeForm(writer, file, u, hasher)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	return pr, writer.FormDataContentType(), done
+}
+
+func writeForm(writer *multipart.Writer, file io.Reader, u Upload, hasher io.Writer) error {
+	// Add pinataOptions for public network
+	options := map[string]interface{}{
+		"cidVersion":        1,
+		"wrapWithDirectory": false,
+	}
+	optionsJSON, err := json.Marshal(options)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		return fmt.Errorf("failed to marshal options: %w", err)
+	}
+	if err := writer.WriteField("pinataOptions", string(optionsJSON)); err != nil {
+		return fmt.Errorf("failed to write pinataOptions: %w", err)
+	}
+	// Add network: public to the form
+	if err := writer.WriteField("network", "public"); err != nil {
+		return fmt.Errorf("failed to write network field: %w", err)
+	}
+
+	if u.GroupID != "" {
+		if err := writer.WriteField("group_id", u.GroupID); err != nil {
+			return fmt.Errorf("failed to write group_id: %w", err)
+		}
+	}
+	if len(u.Keyvalues) > 0 {
+		keyvaluesJSON, err := json.Marshal(u.Keyvalues)
+		if err != nil {
+			return fmt.Errorf("failed to marshal keyvalues: %w", err)
+		}
+		if err := writer.WriteField("keyvalues", string(keyvaluesJSON)); err != nil {
+			return fmt.Errorf("failed to write keyvalues: %w", err)
+		}
 	}
 
-	// Create a temporary file
-	tmpFile, err := os.CreateTemp("", "pinata-*.json")
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteEscaper.Replace(u.Name)))
+	header.Set("Content-Type", u.ContentType)
+	part, err := writer.CreatePart(header)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temporary file: %w", err)
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+	if u.Progress != nil {
+		file = &progressReader{r: file, total: u.Size, progress: u.Progress}
+	}
+	if _, err := io.Copy(io.MultiWriter(part, hasher), file); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
 	}
-	defer os.Remove(tmpFile.Name()) // Clean up the temp file when done
 
-	// Write JSON data to the temporary file
-	if _, err := tmpFile.Write(jsonData); err != nil {
-		tmpFile.Close()
-		return "", fmt.Errorf("failed to write JSON to temporary file: %w", err)
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
 	}
-	tmpFile.Close()
+	return nil
+}
 
-	// Upload the temporary file using the existing UploadFile method
-	return c.UploadFile(tmpFile.Name())
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	progress func(sent, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.progress(p.sent, p.total)
+	}
+	return n, err
 }
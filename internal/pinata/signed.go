@@ -0,0 +1,82 @@
+package pinata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SignOptions limit what a signed upload URL accepts.
+type SignOptions struct {
+	Expires     time.Duration
+	MaxFileSize int64
+	// MimeTypes allowed, wildcards like image/* included. Empty allows any.
+	MimeTypes []string
+	// Name, GroupID and Keyvalues are applied to the uploaded file.
+	Name      string
+	GroupID   string
+	Keyvalues map[string]string
+}
+
+// SignUploadURL returns a URL anyone can upload one public file to with
+// UploadSigned until it expires, without the JWT.
+func (c *PinataClient) SignUploadURL(ctx context.Context, opts SignOptions) (string, error) {
+	if opts.Expires <= 0 {
+		return "", fmt.Errorf("signed URL needs an expiry")
+	}
+	body := map[string]any{
+		"network": "public",
+		"date":    time.Now().Unix(),
+		"expires": int64(opts.Expires / time.Second),
+	}
+	if opts.MaxFileSize > 0 {
+		body["max_file_size"] = opts.MaxFileSize
+	}
+	if len(opts.MimeTypes) > 0 {
+		body["allow_mime_types"] = opts.MimeTypes
+	}
+	if opts.Name != "" {
+		body["filename"] = opts.Name
+	}
+	if opts.GroupID != "" {
+		body["group_id"] = opts.GroupID
+	}
+	if len(opts.Keyvalues) > 0 {
+		body["keyvalues"] = opts.Keyvalues
+	}
+
+	var result struct {
+		Data string `json:"data"`
+	}
+	if err := c.call(ctx, "POST", c.BaseURL, "/v3/files/sign", body, &result); err != nil {
+		return "", err
+	}
+	if result.Data == "" {
+		return "", fmt.Errorf("pinata returned no signed URL")
+	}
+	return result.Data, nil
+}
+
+// UploadSigned uploads r to a URL from SignUploadURL and returns its CID.
+func UploadSigned(ctx context.Context, signedURL string, r io.Reader, u Upload) (string, error) {
+	u.setContentType()
+	return post(ctx, http.DefaultClient, signedURL, "", r, u)
+}
+
+// UploadFileSigned uploads the file at path to a URL from SignUploadURL.
+func UploadFileSigned(ctx context.Context, signedURL, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	return UploadSigned(ctx, signedURL, file, Upload{Name: filepath.Base(path), Size: info.Size()})
+}
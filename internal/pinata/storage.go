@@ -0,0 +1,124 @@
+package pinata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/storage"
+)
+
+var _ storage.Storage = (*PinataClient)(nil)
+
+// File is a file pinned on Pinata.
+type File struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	CID       string            `json:"cid"`
+	Size      int64             `json:"size"`
+	MimeType  string            `json:"mime_type"`
+	GroupID   string            `json:"group_id,omitempty"`
+	Keyvalues map[string]string `json:"keyvalues,omitempty"`
+	CreatedAt string            `json:"created_at"`
+}
+
+func (c *PinataClient) Put(ctx context.Context, name string, r io.Reader) (string, error) {
+	return c.UploadReader(ctx, r, Upload{Name: name})
+}
+
+func (c *PinataClient) URI(cid string) string {
+	return "ipfs://" + cid
+}
+
+// Pin asks Pinata to fetch cid from the IPFS network and pin it. Pinata
+// queues the request, so the file shows up in Stat once it has been found.
+func (c *PinataClient) Pin(ctx context.Context, cid string) error {
+	return c.api(ctx, "POST", "/v3/files/public/pin_by_cid", map[string]string{"cid": cid}, nil)
+}
+
+// Unpin deletes every file with cid.
+func (c *PinataClient) Unpin(ctx context.Context, cid string) error {
+	files, err := c.filesByCID(ctx, cid)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s: %w", cid, storage.ErrNotFound)
+	}
+	for _, file := range files {
+		if err := c.deleteFile(ctx, file.ID); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", file.ID, err)
+		}
+	}
+	if c.Cache != nil {
+		return c.Cache.forget(cid)
+	}
+	return nil
+}
+
+func (c *PinataClient) Stat(ctx context.Context, cid string) (*storage.Object, error) {
+	files, err := c.filesByCID(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: %w", cid, storage.ErrNotFound)
+	}
+	return &storage.Object{CID: files[0].CID, Name: files[0].Name, Size: files[0].Size, Pinned: true}, nil
+}
+
+func (c *PinataClient) filesByCID(ctx context.Context, cid string) ([]File, error) {
+	return c.AllFiles(ctx, FileFilter{CID: cid})
+}
+
+// api calls the Pinata API, sending body as JSON when it is not nil and
+// decoding the response into out when it is not nil.
+func (c *PinataClient) api(ctx context.Context, method, path string, body, out any) error {
+	return c.call(ctx, method, c.APIURL, path, body, out)
+}
+
+func (c *PinataClient) call(ctx context.Context, method, baseURL, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := retry.Value(ctx, retry.Default, "pinata "+path, func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Add("Content-Type", "application/json")
+		}
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.JwtSecret))
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("pinata API error: %w", retry.NewHTTPError(resp))
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
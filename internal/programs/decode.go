@@ -0,0 +1,143 @@
+package programs
+
+import (
+	"fmt"
+
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/near/borsh-go"
+)
+
+// Account roles in the order NewCreateIx, NewBuyIx and NewSellIx lay them out.
+var (
+	CreateAccountRoles = []string{
+		"mint", "mint_authority", "bonding_curve", "associated_bonding_curve",
+		"global", "mpl_token_metadata", "metadata", "user", "system_program",
+		"token_program", "associated_token_program", "rent", "event_authority", "program",
+	}
+	BuyAccountRoles = []string{
+		"global", "fee_recipient", "mint", "bonding_curve", "associated_bonding_curve",
+		"associated_user", "user", "system_program", "token_program", "creator_vault",
+		"event_authority", "program",
+	}
+	SellAccountRoles = []string{
+		"global", "fee_recipient", "mint", "bonding_curve", "associated_bonding_curve",
+		"associated_user", "user", "system_program", "creator_vault", "token_program",
+		"event_authority", "program",
+	}
+)
+
+var (
+	CreateEventDiscriminator   = discriminator("event:CreateEvent")
+	TradeEventDiscriminator    = discriminator("event:TradeEvent")
+	CompleteEventDiscriminator = discriminator("event:CompleteEvent")
+
+	// EventIxTag prefixes self-CPI instructions emitted by Anchor's emit_cpi!.
+	EventIxTag = [8]byte{0xe4, 0x45, 0xa5, 0x2e, 0x51, 0xcb, 0x9a, 0x1d}
+)
+
+type LabeledAccount struct {
+	Role      string
+	PublicKey solana.PublicKey
+}
+
+type DecodedInstruction struct {
+	Name     string
+	Args     interface{}
+	Accounts []LabeledAccount
+}
+
+type DecodedEvent struct {
+	Name  string
+	Event interface{}
+}
+
+// DecodeInstruction identifies a pump.fun instruction by its discriminator,
+// decodes its Borsh arguments and labels its accounts.
+func DecodeInstruction(accounts []solana.PublicKey, data []byte) (*DecodedInstruction, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("instruction data too short: %d bytes", len(data))
+	}
+
+	var disc [8]byte
+	copy(disc[:], data[:8])
+
+	var (
+		decoded DecodedInstruction
+		roles   []string
+		err     error
+	)
+	switch disc {
+	case CreateDiscriminator:
+		var args types.CreateData
+		err = borsh.Deserialize(&args, data[8:])
+		decoded.Name, decoded.Args, roles = "create", args, CreateAccountRoles
+	case BuyDiscriminator:
+		var args types.BuyData
+		err = borsh.Deserialize(&args, data[8:])
+		decoded.Name, decoded.Args, roles = "buy", args, BuyAccountRoles
+	case SellDiscriminator:
+		var args types.SellData
+		err = borsh.Deserialize(&args, data[8:])
+		decoded.Name, decoded.Args, roles = "sell", args, SellAccountRoles
+	case EventIxTag:
+		event, eventErr := DecodeEvent(data[8:])
+		if eventErr != nil {
+			return nil, eventErr
+		}
+		return &DecodedInstruction{Name: "emit " + event.Name, Args: event.Event}, nil
+	default:
+		return nil, fmt.Errorf("unknown pump.fun instruction %x", disc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing %s args: %w", decoded.Name, err)
+	}
+
+	for i, account := range accounts {
+		role := fmt.Sprintf("remaining_%d", i-len(roles))
+		if i < len(roles) {
+			role = roles[i]
+		}
+		decoded.Accounts = append(decoded.Accounts, LabeledAccount{Role: role, PublicKey: account})
+	}
+
+	return &decoded, nil
+}
+
+// DecodeEvent decodes an Anchor event, discriminator followed by Borsh data,
+// as found in "Program data:" logs or emit_cpi! instructions.
+func DecodeEvent(data []byte) (*DecodedEvent, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("event data too short: %d bytes", len(data))
+	}
+
+	var disc [8]byte
+	copy(disc[:], data[:8])
+
+	var (
+		event DecodedEvent
+		err   error
+	)
+	switch disc {
+	case CreateEventDiscriminator:
+		var e types.CreateEvent
+		err = borsh.Deserialize(&e, data[8:])
+		event.Name, event.Event = "CreateEvent", e
+	case TradeEventDiscriminator:
+		var e types.TradeEvent
+		err = borsh.Deserialize(&e, data[8:])
+		event.Name, event.Event = "TradeEvent", e
+	case CompleteEventDiscriminator:
+		var e types.CompleteEvent
+		err = borsh.Deserialize(&e, data[8:])
+		event.Name, event.Event = "CompleteEvent", e
+	default:
+		return nil, fmt.Errorf("unknown pump.fun event %x", disc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing %s: %w", event.Name, err)
+	}
+
+	return &event, nil
+}
@@ -10,6 +10,20 @@ import (
 	"github.com/near/borsh-go"
 )
 
+var (
+	CreateDiscriminator = discriminator("global:create")
+	BuyDiscriminator    = discriminator("global:buy")
+	SellDiscriminator   = discriminator("global:sell")
+)
+
+// discriminator returns the 8-byte Anchor instruction discriminator.
+func discriminator(name string) [8]byte {
+	var d [8]byte
+	sum := sha256.Sum256([]byte(name))
+	copy(d[:], sum[:8])
+	return d
+}
+
 func NewBuyIx(
 	amount uint64,
 	maxSolCost uint64,
@@ -20,13 +34,11 @@ func NewBuyIx(
 ) *solana.GenericInstruction {
 	program := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
 
-	buyDiscriminator := sha256.Sum256([]byte("global:buy"))
-
 	argsBin, _ := borsh.Serialize(types.BuyData{
 		Amount:     amount,
 		MaxSolCost: maxSolCost,
 	})
-	data := append(buyDiscriminator[:8], argsBin...)
+	data := append(BuyDiscriminator[:], argsBin...)
 
 	// Derive PDAs
 	global, _, _ := DeriveGlobal(program)
@@ -54,6 +66,69 @@ func NewBuyIx(
 	}
 }
 
+func NewSellIx(
+	amount uint64,
+	minSolOutput uint64,
+	feeRecipient, mint,
+	assocBondingCurve, assocUser,
+	user, systemProgram, creatorVault, tokenProgram,
+	eventAuthority solana.PublicKey,
+) *solana.GenericInstruction {
+	program := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
+
+	argsBin, _ := borsh.Serialize(types.SellData{
+		Amount:       amount,
+		MinSolOutput: minSolOutput,
+	})
+	data := append(SellDiscriminator[:], argsBin...)
+
+	// Derive PDAs
+	global, _, _ := DeriveGlobal(program)
+	bondingCurve, _, _ := DeriveBondingCurve(mint, program)
+
+	metas := solana.AccountMetaSlice{
+		{PublicKey: global, IsWritable: false, IsSigner: false},
+		{PublicKey: feeRecipient, IsWritable: true, IsSigner: false},
+		{PublicKey: mint, IsWritable: false, IsSigner: false},
+		{PublicKey: bondingCurve, IsWritable: true, IsSigner: false},
+		{PublicKey: assocBondingCurve, IsWritable: true, IsSigner: false},
+		{PublicKey: assocUser, IsWritable: true, IsSigner: false},
+		{PublicKey: user, IsWritable: true, IsSigner: true},
+		{PublicKey: systemProgram, IsWritable: false, IsSigner: false},
+		{PublicKey: creatorVault, IsWritable: true, IsSigner: false},
+		{PublicKey: tokenProgram, IsWritable: false, IsSigner: false},
+		{PublicKey: eventAuthority, IsWritable: false, IsSigner: false},
+		{PublicKey: program, IsWritable: false, IsSigner: false},
+	}
+
+	return &solana.GenericInstruction{
+		AccountValues: metas,
+		ProgID:        program,
+		DataBytes:     data,
+	}
+}
+
+// NewCreateIdempotentATAIx creates the wallet's associated token account for
+// mint, succeeding without changes if it already exists.
+func NewCreateIdempotentATAIx(payer, wallet, mint solana.PublicKey) *solana.GenericInstruction {
+	ata, _, _ := DeriveAssociatedTokenAccount(wallet, mint)
+
+	metas := solana.AccountMetaSlice{
+		{PublicKey: payer, IsWritable: true, IsSigner: true},
+		{PublicKey: ata, IsWritable: true, IsSigner: false},
+		{PublicKey: wallet, IsWritable: false, IsSigner: false},
+		{PublicKey: mint, IsWritable: false, IsSigner: false},
+		{PublicKey: solana.SystemProgramID, IsWritable: false, IsSigner: false},
+		{PublicKey: solana.TokenProgramID, IsWritable: false, IsSigner: false},
+	}
+
+	return &solana.GenericInstruction{
+		AccountValues: metas,
+		ProgID:        associatedtokenaccount.ProgramID,
+		DataBytes:     []byte{1},
+	}
+}
+
 func NewCreateIx(
 	mint,
 	user solana.PublicKey,
@@ -61,13 +136,11 @@ func NewCreateIx(
 ) *solana.GenericInstruction {
 	program := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
 
-	createDiscriminator := sha256.Sum256([]byte("global:create"))
-
 	argsBin, _ := borsh.Serialize(createData)
-	data := append(createDiscriminator[:8], argsBin...)
+	data := append(CreateDiscriminator[:], argsBin...)
 
 	mplTokenMetadata, _ := solana.PublicKeyFromBase58("metaqbxxUerdq28cj1RbAWkYQm3ybzjb6a8bt518x1s")
-	eventAuthority, _ := solana.PublicKeyFromBase58("Ce6TQqeHC9p8KetsN6JsjHK7UTZk7nasjjnr7XxXp9F1")
+	eventAuthority, _ := solana.PublicKeyFromBase58(internal.EVENT_AUTHORITY)
 
 	mintAuthority, _, _ := DeriveMintAuthority(program)
 	bondingCurve, _, _ := DeriveBondingCurve(mint, program)
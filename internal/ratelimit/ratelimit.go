@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket that callers wait on.
+type Bucket struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+	waiting   int
+	throttled uint64
+}
+
+// NewBucket allows rate requests per second with bursts of up to burst. A
+// zero rate never throttles.
+func NewBucket(rate float64, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *Bucket) Wait(ctx context.Context) error {
+	if b.rate <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	// Reserve the token now so waiters are served in order.
+	b.tokens--
+	if b.tokens >= 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.waiting++
+	b.throttled++
+	b.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		b.mu.Lock()
+		b.waiting--
+		b.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.waiting--
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Stats is a snapshot of a bucket.
+type Stats struct {
+	Rate float64
+	// Waiting is the number of callers currently queued.
+	Waiting int
+	// Throttled counts calls that had to wait.
+	Throttled uint64
+}
+
+func (b *Bucket) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Stats{Rate: b.rate, Waiting: b.waiting, Throttled: b.throttled}
+}
+
+// Limits maps a method class to requests per second.
+type Limits map[string]float64
+
+// ParseLimits reads "send=5,read=40,gpa=1". Unlisted classes keep the value
+// from base.
+func ParseLimits(spec string, base Limits) (Limits, error) {
+	limits := make(Limits, len(base))
+	for class, rate := range base {
+		limits[class] = rate
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		class, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q, expected class=rate", entry)
+		}
+		if class != ClassSend && class != ClassRead && class != ClassProgramAccounts {
+			return nil, fmt.Errorf("unknown rate limit class %q", class)
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate in %q: %w", entry, err)
+		}
+		limits[class] = rate
+	}
+	return limits, nil
+}
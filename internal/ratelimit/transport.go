@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+)
+
+// Method classes.
+const (
+	ClassSend            = "send"
+	ClassRead            = "read"
+	ClassProgramAccounts = "gpa"
+)
+
+// DefaultLimits stays under the free tiers of the common providers.
+var DefaultLimits = Limits{
+	ClassSend:            5,
+	ClassRead:            40,
+	ClassProgramAccounts: 1,
+}
+
+// Limiter holds the buckets of one endpoint.
+type Limiter struct {
+	limits  Limits
+	buckets map[string]*Bucket
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Limiter)
+)
+
+// ForEndpoint returns the limiter for url, creating it on first use, so every
+// client in the process talking to the same endpoint shares its budget. Asking
+// for an existing endpoint with different limits is an error.
+func ForEndpoint(url string, limits Limits) (*Limiter, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if limiter, ok := registry[url]; ok {
+		for _, class := range []string{ClassSend, ClassRead, ClassProgramAccounts} {
+			if limiter.limits[class] != limits[class] {
+				return nil, fmt.Errorf("%s is already limited to %s=%g, not %g", url, class, limiter.limits[class], limits[class])
+			}
+		}
+		return limiter, nil
+	}
+
+	limiter := &Limiter{limits: limits, buckets: make(map[string]*Bucket)}
+	for _, class := range []string{ClassSend, ClassRead, ClassProgramAccounts} {
+		rate := limits[class]
+		limiter.buckets[class] = NewBucket(rate, int(math.Ceil(rate)))
+	}
+	registry[url] = limiter
+	return limiter, nil
+}
+
+// Lookup returns the limiter already created for url.
+func Lookup(url string) (*Limiter, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	limiter, ok := registry[url]
+	return limiter, ok
+}
+
+// Stats returns the state of each class's bucket.
+func (l *Limiter) Stats() map[string]Stats {
+	stats := make(map[string]Stats, len(l.buckets))
+	for class, bucket := range l.buckets {
+		stats[class] = bucket.Stats()
+	}
+	return stats
+}
+
+// Transport waits on the bucket matching each JSON-RPC request's method
+// before passing it on.
+type Transport struct {
+	Base    http.RoundTripper
+	Limiter *Limiter
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	class := ClassRead
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// The caller's request must not be modified, the read body goes on
+		// a copy.
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		class = classify(body)
+	}
+
+	if err := t.Limiter.buckets[class].Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base().RoundTrip(req)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// classify picks the class of a single or batched JSON-RPC request. A batch
+// takes the most restricted class among its methods.
+func classify(body []byte) string {
+	var requests []struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &requests); err != nil {
+		var single struct {
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(body, &single); err != nil {
+			return ClassRead
+		}
+		requests = append(requests, single)
+	}
+
+	class := ClassRead
+	for _, r := range requests {
+		switch r.Method {
+		case "getProgramAccounts":
+			return ClassProgramAccounts
+		case "sendTransaction":
+			class = ClassSend
+		}
+	}
+	return class
+}
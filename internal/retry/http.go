@@ -0,0 +1,72 @@
+package retry
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPError is a failed HTTP response, keeping the Retry-After hint.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s - %s", e.Status, e.Body)
+}
+
+// NewHTTPError reads and closes the body of a failed response.
+func NewHTTPError(resp *http.Response) *HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// StatusClient turns 429 and 5xx responses into *HTTPError so the Retry-After
+// header survives clients, like the Solana JSON-RPC client, that drop it.
+type StatusClient struct {
+	Client *http.Client
+}
+
+func NewStatusClient(client *http.Client) *StatusClient {
+	return &StatusClient{Client: client}
+}
+
+func (c *StatusClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, NewHTTPError(resp)
+	}
+	return resp, nil
+}
+
+func (c *StatusClient) CloseIdleConnections() {
+	c.Client.CloseIdleConnections()
+}
@@ -0,0 +1,211 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"pf-launcher/internal/txerrors"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// Class says how an error should be treated by the retry loop.
+type Class int
+
+const (
+	Permanent Class = iota
+	Transient
+	RateLimited
+	BlockhashExpired
+	Timeout
+	// Rebuild is a decoded transaction failure that a rebuilt and re-signed
+	// transaction may get past, while resending the same one cannot.
+	Rebuild
+)
+
+func (c Class) String() string {
+	switch c {
+	case Permanent:
+		return "permanent"
+	case Transient:
+		return "transient"
+	case RateLimited:
+		return "rate limited"
+	case BlockhashExpired:
+		return "blockhash expired"
+	case Timeout:
+		return "timeout"
+	case Rebuild:
+		return "needs rebuild"
+	}
+	return "unknown"
+}
+
+// Retryable reports whether another attempt can succeed without changes.
+// An expired blockhash or a Rebuild error needs a new transaction instead.
+func (c Class) Retryable() bool {
+	return c == Transient || c == RateLimited || c == Timeout
+}
+
+// Policy is an exponential backoff with jitter.
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter is the fraction of each delay that is randomised, from 0 to 1.
+	Jitter float64
+}
+
+var (
+	// Default suits reads and uploads.
+	Default = Policy{MaxAttempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second, Jitter: 0.5}
+	// Send retries transaction submission quickly, before the blockhash expires.
+	Send = Policy{MaxAttempts: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 2 * time.Second, Jitter: 0.5}
+)
+
+// Classify decides whether err is worth retrying.
+func Classify(err error) Class {
+	if err == nil {
+		return Permanent
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, rpc.ErrNotFound) {
+		return Permanent
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return classifyStatus(httpErr.StatusCode)
+	}
+	var rpcHTTPErr *jsonrpc.HTTPError
+	if errors.As(err, &rpcHTTPErr) {
+		return classifyStatus(rpcHTTPErr.Code)
+	}
+
+	var txErr *txerrors.TransactionError
+	if errors.As(err, &txErr) {
+		switch {
+		case errors.Is(txErr, txerrors.ErrBlockhashNotFound):
+			return BlockhashExpired
+		case txErr.Retryable:
+			return Rebuild
+		default:
+			return Permanent
+		}
+	}
+
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		if strings.Contains(strings.ToLower(rpcErr.Message), "blockhash not found") {
+			return BlockhashExpired
+		}
+		switch rpcErr.Code {
+		case 429, -32429:
+			return RateLimited
+		case -32600, -32601, -32602, -32002:
+			// Malformed requests and preflight failures txerrors could not
+			// decode will fail the same way again.
+			return Permanent
+		}
+		return Transient
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return Timeout
+		}
+		return Transient
+	}
+
+	return Transient
+}
+
+func classifyStatus(status int) Class {
+	switch {
+	case status == 429:
+		return RateLimited
+	case status == 408:
+		return Timeout
+	case status >= 500:
+		return Transient
+	default:
+		return Permanent
+	}
+}
+
+// Do calls fn until it succeeds, returns an error that is not worth retrying,
+// runs out of attempts or ctx is done. op names the call in logs.
+func Do(ctx context.Context, p Policy, op string, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < p.MaxAttempts; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		class := Classify(err)
+		if !class.Retryable() || attempt == p.MaxAttempts-1 {
+			break
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: %w (last error: %v)", op, ctx.Err(), err)
+		}
+
+		delay := p.delay(attempt)
+		if after := retryAfter(err); after > delay {
+			delay = after
+		}
+		log.Printf("Attempt %d: %s failed (%s): %v, retrying in %s", attempt+1, op, class, err, delay.Round(time.Millisecond))
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("%s: no time left to retry: %w", op, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %w (last error: %v)", op, ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+// Value is Do for calls that return a result.
+func Value[T any](ctx context.Context, p Policy, op string, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := Do(ctx, p, op, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (p Policy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+func retryAfter(err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,194 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"pf-launcher/internal"
+	"pf-launcher/internal/txerrors"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// pumpFunError is the decoded failure of custom error code in a pump.fun
+// instruction.
+func pumpFunError(code uint32) error {
+	tx := &solana.Transaction{Message: solana.Message{
+		AccountKeys:  solana.PublicKeySlice{solana.NewWallet().PublicKey(), solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)},
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1}},
+	}}
+	metaErr := map[string]interface{}{"InstructionError": []interface{}{float64(0), map[string]interface{}{"Custom": float64(code)}}}
+	return txerrors.FromMetaErr(metaErr, tx, nil)
+}
+
+func httpResponse(status int, header http.Header) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("body")),
+	}
+}
+
+type netError struct{ timeout bool }
+
+func (e netError) Error() string   { return "net error" }
+func (e netError) Timeout() bool   { return e.timeout }
+func (e netError) Temporary() bool { return false }
+
+var _ net.Error = netError{}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{"nil", nil, Permanent},
+		{"canceled", context.Canceled, Permanent},
+		{"wrapped canceled", fmt.Errorf("send: %w", context.Canceled), Permanent},
+		{"deadline", context.DeadlineExceeded, Timeout},
+		{"not found", rpc.ErrNotFound, Permanent},
+
+		{"http 429", NewHTTPError(httpResponse(429, nil)), RateLimited},
+		{"http 408", NewHTTPError(httpResponse(408, nil)), Timeout},
+		{"http 500", NewHTTPError(httpResponse(500, nil)), Transient},
+		{"http 502", NewHTTPError(httpResponse(502, nil)), Transient},
+		{"http 503", fmt.Errorf("pinata API error: %w", NewHTTPError(httpResponse(503, nil))), Transient},
+		{"http 400", NewHTTPError(httpResponse(400, nil)), Permanent},
+		{"http 401", NewHTTPError(httpResponse(401, nil)), Permanent},
+		{"rpc http 429", &jsonrpc.HTTPError{Code: 429}, RateLimited},
+		{"rpc http 503", &jsonrpc.HTTPError{Code: 503}, Transient},
+
+		{"blockhash not found", txerrors.FromMetaErr("BlockhashNotFound", nil, nil), BlockhashExpired},
+		{"already processed", txerrors.FromMetaErr("AlreadyProcessed", nil, nil), Permanent},
+		{"account in use", txerrors.FromMetaErr("AccountInUse", nil, nil), Rebuild},
+		{"pump.fun slippage", pumpFunError(6002), Rebuild},
+		{"pump.fun curve complete", pumpFunError(6005), Permanent},
+		{"pump.fun not authorized", pumpFunError(6000), Permanent},
+
+		{"rpc blockhash not found", &jsonrpc.RPCError{Code: -32002, Message: "Transaction simulation failed: Blockhash not found"}, BlockhashExpired},
+		{"rpc 429", &jsonrpc.RPCError{Code: 429, Message: "Too many requests"}, RateLimited},
+		{"rpc -32429", &jsonrpc.RPCError{Code: -32429, Message: "rate limited"}, RateLimited},
+		{"rpc undecoded preflight", &jsonrpc.RPCError{Code: -32002, Message: "Transaction simulation failed"}, Permanent},
+		{"rpc invalid params", &jsonrpc.RPCError{Code: -32602, Message: "invalid params"}, Permanent},
+		{"rpc node behind", &jsonrpc.RPCError{Code: -32005, Message: "Node is behind"}, Transient},
+
+		{"net timeout", netError{timeout: true}, Timeout},
+		{"net reset", netError{}, Transient},
+		// Anything unrecognised is assumed to be a passing failure.
+		{"unknown", errors.New("connection reset by peer"), Transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	want := map[Class]bool{
+		Permanent:        false,
+		Transient:        true,
+		RateLimited:      true,
+		BlockhashExpired: false,
+		Timeout:          true,
+		Rebuild:          false,
+	}
+	for class, retryable := range want {
+		if class.Retryable() != retryable {
+			t.Errorf("%s.Retryable() = %v, want %v", class, class.Retryable(), retryable)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := NewHTTPError(httpResponse(429, http.Header{"Retry-After": []string{"7"}}))
+	if err.Body != "body" {
+		t.Errorf("Body = %q", err.Body)
+	}
+	if got := retryAfter(fmt.Errorf("upload: %w", err)); got != 7*time.Second {
+		t.Errorf("retryAfter = %s, want 7s", got)
+	}
+
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	err = NewHTTPError(httpResponse(503, http.Header{"Retry-After": []string{at}}))
+	if got := retryAfter(err); got < 55*time.Second || got > time.Minute {
+		t.Errorf("retryAfter of an HTTP date = %s, want about a minute", got)
+	}
+	if got := retryAfter(errors.New("eof")); got != 0 {
+		t.Errorf("retryAfter without a header = %s", got)
+	}
+}
+
+var fast = Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"transient is retried", NewHTTPError(httpResponse(503, nil)), 3},
+		{"rate limit is retried", &jsonrpc.RPCError{Code: 429}, 3},
+		{"permanent is not", NewHTTPError(httpResponse(400, nil)), 1},
+		{"expired blockhash is not", txerrors.FromMetaErr("BlockhashNotFound", nil, nil), 1},
+		{"rebuild is not", pumpFunError(6002), 1},
+		{"already processed is not", txerrors.FromMetaErr("AlreadyProcessed", nil, nil), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(context.Background(), fast, "test", func(ctx context.Context) error {
+				attempts++
+				return tt.err
+			})
+			if err != tt.err {
+				t.Errorf("Do = %v, want the last error", err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("%d attempts, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestDoSucceeds(t *testing.T) {
+	attempts := 0
+	got, err := Value(context.Background(), fast, "test", func(ctx context.Context) (int, error) {
+		if attempts++; attempts < 2 {
+			return 0, NewHTTPError(httpResponse(502, nil))
+		}
+		return 42, nil
+	})
+	if err != nil || got != 42 || attempts != 2 {
+		t.Errorf("Value = %d, %v after %d attempts, want 42 after 2", got, err, attempts)
+	}
+}
+
+func TestDoStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := Do(ctx, Policy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}, "test", func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return NewHTTPError(httpResponse(503, nil))
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Do = %v, want canceled", err)
+	}
+	if attempts != 1 {
+		t.Errorf("%d attempts, want 1", attempts)
+	}
+}
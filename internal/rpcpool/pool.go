@@ -0,0 +1,286 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/txerrors"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// ewmaWeight is how much each new sample moves latency and error rate.
+	ewmaWeight = 0.2
+	// maxErrorRate marks an endpoint unhealthy once its smoothed error rate
+	// goes above it.
+	maxErrorRate = 0.5
+)
+
+// Endpoint is one RPC provider with its health statistics.
+type Endpoint struct {
+	URL    string
+	Client *rpc.Client
+
+	mu        sync.Mutex
+	latency   time.Duration
+	errorRate float64
+	slot      uint64
+	lagging   bool
+	lastErr   error
+}
+
+func NewEndpoint(url string, client *rpc.Client) *Endpoint {
+	return &Endpoint{URL: url, Client: client}
+}
+
+// EndpointStats is a snapshot of an endpoint's health.
+type EndpointStats struct {
+	URL       string
+	Latency   time.Duration
+	ErrorRate float64
+	Slot      uint64
+	Lagging   bool
+	Healthy   bool
+	LastError error
+}
+
+// Pool spreads reads over the healthiest endpoint, failing over on errors,
+// and fans sends out to every healthy endpoint.
+type Pool struct {
+	endpoints  []*Endpoint
+	maxSlotLag uint64
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// New creates a pool and starts probing the endpoints' slots every interval.
+func New(endpoints []*Endpoint, maxSlotLag uint64, interval time.Duration) (*Pool, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no RPC endpoints configured")
+	}
+
+	p := &Pool{
+		endpoints:  endpoints,
+		maxSlotLag: maxSlotLag,
+		stop:       make(chan struct{}),
+	}
+	go p.probeLoop(interval)
+	return p, nil
+}
+
+func (p *Pool) Close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// Stats returns the health of every endpoint.
+func (p *Pool) Stats() []EndpointStats {
+	stats := make([]EndpointStats, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		stats = append(stats, EndpointStats{
+			URL:       e.URL,
+			Latency:   e.latency,
+			ErrorRate: e.errorRate,
+			Slot:      e.slot,
+			Lagging:   e.lagging,
+			Healthy:   e.healthyLocked(),
+			LastError: e.lastErr,
+		})
+		e.mu.Unlock()
+	}
+	return stats
+}
+
+// Read calls fn on the best endpoint and fails over to the next one while
+// the error is retryable.
+func Read[T any](ctx context.Context, p *Pool, fn func(ctx context.Context, client *rpc.Client) (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+	for _, e := range p.ranked() {
+		start := time.Now()
+		result, err = fn(ctx, e.Client)
+		e.record(time.Since(start), err)
+		if err == nil || !retry.Classify(err).Retryable() || ctx.Err() != nil {
+			return result, err
+		}
+		log.Printf("RPC %s failed, failing over: %v", e.URL, err)
+	}
+	return result, err
+}
+
+// Broadcast calls fn on every healthy endpoint at once and returns the first
+// success. When all fail, a decoded transaction error is preferred over
+// transport errors since it explains the failure.
+func Broadcast[T any](ctx context.Context, p *Pool, fn func(ctx context.Context, client *rpc.Client) (T, error)) (T, error) {
+	type outcome struct {
+		result T
+		err    error
+	}
+
+	endpoints := p.healthy()
+	outcomes := make(chan outcome, len(endpoints))
+	for _, e := range endpoints {
+		go func(e *Endpoint) {
+			start := time.Now()
+			result, err := fn(ctx, e.Client)
+			e.record(time.Since(start), err)
+			outcomes <- outcome{result, err}
+		}(e)
+	}
+
+	var (
+		zero     T
+		firstErr error
+	)
+	for range endpoints {
+		o := <-outcomes
+		if o.err == nil {
+			return o.result, nil
+		}
+		var txErr *txerrors.TransactionError
+		if firstErr == nil || errors.As(o.err, &txErr) {
+			firstErr = o.err
+		}
+	}
+	return zero, firstErr
+}
+
+// healthy returns the healthy endpoints, or all of them when none is
+// healthy so that a call is still attempted.
+func (p *Pool) healthy() []*Endpoint {
+	var out []*Endpoint
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		if e.healthyLocked() {
+			out = append(out, e)
+		}
+		e.mu.Unlock()
+	}
+	if len(out) == 0 {
+		return p.endpoints
+	}
+	return out
+}
+
+// ranked orders endpoints with healthy ones first, then by error rate and
+// latency.
+func (p *Pool) ranked() []*Endpoint {
+	type scored struct {
+		e       *Endpoint
+		healthy bool
+		score   float64
+	}
+
+	all := make([]scored, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		// An error costs as much as a full second of latency.
+		score := float64(e.latency.Milliseconds()) + e.errorRate*1000
+		all = append(all, scored{e, e.healthyLocked(), score})
+		e.mu.Unlock()
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].healthy != all[j].healthy {
+			return all[i].healthy
+		}
+		return all[i].score < all[j].score
+	})
+
+	out := make([]*Endpoint, len(all))
+	for i, s := range all {
+		out[i] = s.e
+	}
+	return out
+}
+
+func (e *Endpoint) healthyLocked() bool {
+	return !e.lagging && e.errorRate <= maxErrorRate
+}
+
+func (e *Endpoint) record(latency time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Errors the endpoint is not responsible for, like a failed simulation,
+	// do not count against it.
+	var txErr *txerrors.TransactionError
+	failed := err != nil && !errors.As(err, &txErr) && retry.Classify(err).Retryable()
+	sample := 0.0
+	if failed {
+		sample = 1
+		e.lastErr = err
+	}
+	e.errorRate += ewmaWeight * (sample - e.errorRate)
+
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency += time.Duration(ewmaWeight * float64(latency-e.latency))
+	}
+}
+
+func (p *Pool) probeLoop(interval time.Duration) {
+	p.probe()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.probe()
+		}
+	}
+}
+
+// probe fetches every endpoint's slot and marks the ones that fall more than
+// maxSlotLag behind the highest.
+func (p *Pool) probe() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *Endpoint) {
+			defer wg.Done()
+			start := time.Now()
+			slot, err := e.Client.GetSlot(ctx, rpc.CommitmentProcessed)
+			e.record(time.Since(start), err)
+			if err == nil {
+				e.mu.Lock()
+				e.slot = slot
+				e.mu.Unlock()
+			}
+		}(e)
+	}
+	wg.Wait()
+
+	var highest uint64
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		if e.slot > highest {
+			highest = e.slot
+		}
+		e.mu.Unlock()
+	}
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		lagging := e.slot+p.maxSlotLag < highest
+		if lagging && !e.lagging {
+			log.Printf("RPC %s is %d slots behind, excluding it", e.URL, highest-e.slot)
+		}
+		e.lagging = lagging
+		e.mu.Unlock()
+	}
+}
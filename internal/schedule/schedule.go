@@ -0,0 +1,282 @@
+package schedule
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"pf-launcher/internal/offline"
+	"pf-launcher/internal/types"
+)
+
+const (
+	StatePending   = "pending"
+	StateSending   = "sending"
+	StateConfirmed = "confirmed"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// Schedule is a launch queued to fire at a UTC time or a slot. Metadata is
+// uploaded when the schedule is created. Envelope holds a pre-signed durable
+// nonce transaction when one was built; otherwise the transaction is built
+// when the schedule fires. Signature and LastValidBlockHeight are saved
+// before sending, so a schedule interrupted while sending can be settled.
+type Schedule struct {
+	ID                   string            `json:"id"`
+	At                   time.Time         `json:"at,omitempty"`
+	Slot                 uint64            `json:"slot,omitempty"`
+	Metadata             types.Metadata    `json:"metadata"`
+	MetadataURI          string            `json:"metadataUri"`
+	BuyLamports          uint64            `json:"buyLamports"`
+	Envelope             *offline.Envelope `json:"envelope,omitempty"`
+	State                string            `json:"state"`
+	Mint                 string            `json:"mint,omitempty"`
+	Signature            string            `json:"signature,omitempty"`
+	LastValidBlockHeight uint64            `json:"lastValidBlockHeight,omitempty"`
+	Error                string            `json:"error,omitempty"`
+	CreatedAt            time.Time         `json:"createdAt"`
+	UpdatedAt            time.Time         `json:"updatedAt"`
+}
+
+var (
+	ErrNotFound = errors.New("schedule not found")
+	// ErrState is returned when a schedule is not in the state a transition
+	// starts from.
+	ErrState = errors.New("schedule is in another state")
+)
+
+const (
+	runnerLock = "runner"
+	// lockWait is how long Transition waits for another process to release
+	// a schedule, which it only holds while rewriting the file. A lock older
+	// than lockStale was left by a process that died holding it.
+	lockWait  = 2 * time.Second
+	lockStale = 30 * time.Second
+)
+
+func New(metadata types.Metadata, metadataURI string, buyLamports uint64) (*Schedule, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate schedule id: %w", err)
+	}
+	now := time.Now().UTC()
+	return &Schedule{
+		ID:          now.Format("20060102T150405") + "-" + hex.EncodeToString(id),
+		Metadata:    metadata,
+		MetadataURI: metadataURI,
+		BuyLamports: buyLamports,
+		State:       StatePending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// Target describes when the schedule fires.
+func (s *Schedule) Target() string {
+	if s.Slot > 0 {
+		return fmt.Sprintf("slot %d", s.Slot)
+	}
+	return s.At.UTC().Format(time.RFC3339Nano)
+}
+
+func (s *Schedule) String() string {
+	line := fmt.Sprintf("%s  %-9s  %s  %s (%s)", s.ID, s.State, s.Target(), s.Metadata.Name, s.Metadata.Symbol)
+	if s.Envelope != nil {
+		line += "  pre-signed"
+	}
+	if s.Mint != "" {
+		line += "  mint " + s.Mint
+	}
+	if s.Signature != "" {
+		line += "  sig " + s.Signature
+	}
+	if s.Error != "" {
+		line += "  error: " + s.Error
+	}
+	return line
+}
+
+// Store keeps one JSON file per schedule in a directory, so schedules can be
+// listed and cancelled from another process while a runner waits on them.
+type Store struct {
+	Dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+func (st *Store) path(id string) string {
+	return filepath.Join(st.Dir, id+".json")
+}
+
+// Save writes s atomically. The file holds a signed transaction, so it is
+// only readable by the owner.
+func (st *Store) Save(s *Schedule) error {
+	if err := os.MkdirAll(st.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create schedule directory: %w", err)
+	}
+	s.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
+	tmp := st.path(s.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write schedule: %w", err)
+	}
+	if err := os.Rename(tmp, st.path(s.ID)); err != nil {
+		return fmt.Errorf("failed to write schedule: %w", err)
+	}
+	return nil
+}
+
+func (st *Store) Load(id string) (*Schedule, error) {
+	data, err := os.ReadFile(st.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule: %w", err)
+	}
+
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+// List returns every schedule, soonest first.
+func (st *Store) List() ([]*Schedule, error) {
+	entries, err := os.ReadDir(st.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule directory: %w", err)
+	}
+
+	var schedules []*Schedule
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		s, err := st.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		a, b := schedules[i], schedules[j]
+		if (a.Slot > 0) != (b.Slot > 0) {
+			return a.Slot == 0
+		}
+		if a.Slot > 0 {
+			return a.Slot < b.Slot
+		}
+		return a.At.Before(b.At)
+	})
+	return schedules, nil
+}
+
+// Cancel stops a pending schedule from firing.
+func (st *Store) Cancel(id string) error {
+	_, err := st.Transition(id, StatePending, StateCancelled)
+	if errors.Is(err, ErrState) {
+		return fmt.Errorf("schedule %s is not pending, only pending schedules can be cancelled", id)
+	}
+	return err
+}
+
+// Transition moves a schedule from one state to another while holding its
+// lock, so two processes can't both fire it and a cancel racing a fire is
+// never overwritten.
+func (st *Store) Transition(id, from, to string) (*Schedule, error) {
+	unlock, err := st.lockSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	s, err := st.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.State != from {
+		return nil, fmt.Errorf("%w: %s is %s", ErrState, id, s.State)
+	}
+	s.State = to
+	if err := st.Save(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LockRunner claims the directory for one schedule-run, so two runners
+// never fire the same schedules. The returned func releases it.
+func (st *Store) LockRunner() (func(), error) {
+	unlock, err := st.lock(runnerLock)
+	if errors.Is(err, os.ErrExist) {
+		pid, _ := os.ReadFile(st.lockPath(runnerLock))
+		return nil, fmt.Errorf("another schedule-run (pid %s) is using %s, delete %s if it is not running",
+			strings.TrimSpace(string(pid)), st.Dir, st.lockPath(runnerLock))
+	}
+	return unlock, err
+}
+
+func (st *Store) lockSchedule(id string) (func(), error) {
+	deadline := time.Now().Add(lockWait)
+	for {
+		unlock, err := st.lock(id)
+		if !errors.Is(err, os.ErrExist) {
+			return unlock, err
+		}
+		if info, err := os.Stat(st.lockPath(id)); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(st.lockPath(id))
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("schedule %s is locked by another process", id)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (st *Store) lockPath(name string) string {
+	return filepath.Join(st.Dir, name+".lock")
+}
+
+// lock creates name.lock holding the process id, failing with os.ErrExist
+// while another process holds it.
+func (st *Store) lock(name string) (func(), error) {
+	if err := os.MkdirAll(st.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create schedule directory: %w", err)
+	}
+	path := st.lockPath(name)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", name, err)
+	}
+	_, err = fmt.Fprintln(file, os.Getpid())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write lock %s: %w", path, err)
+	}
+	return func() { os.Remove(path) }, nil
+}
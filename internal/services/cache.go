@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"pf-launcher/internal/inspect"
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// blockhashRefresh is how often a prewarmed client fetches a blockhash.
+	blockhashRefresh = 2 * time.Second
+	// blockhashMaxAge is how long a cached blockhash is used. It stays well
+	// inside the ~60 seconds a blockhash lives.
+	blockhashMaxAge = 20 * time.Second
+	// globalAccountTTL is how long the decoded global account is reused.
+	globalAccountTTL = time.Minute
+)
+
+// chainCache keeps a recent blockhash and the global account so the launch
+// path does not wait on them.
+type chainCache struct {
+	mu          sync.Mutex
+	blockhash   *rpc.GetLatestBlockhashResult
+	blockhashAt time.Time
+	global      *types.GlobalAccount
+	globalAt    time.Time
+	// rent does not change between epochs in practice, so it is kept for
+	// the life of the client.
+	rent inspect.Rent
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	started  sync.Once
+}
+
+func newChainCache() *chainCache {
+	return &chainCache{stop: make(chan struct{}), rent: inspect.Rent{}}
+}
+
+func (cc *chainCache) getRent(size uint64) (uint64, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	rent, ok := cc.rent[size]
+	return rent, ok
+}
+
+func (cc *chainCache) setRent(size, rent uint64) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.rent[size] = rent
+}
+
+func (cc *chainCache) getBlockhash() *rpc.GetLatestBlockhashResult {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.blockhash == nil || time.Since(cc.blockhashAt) > blockhashMaxAge {
+		return nil
+	}
+	return cc.blockhash
+}
+
+func (cc *chainCache) setBlockhash(bh *rpc.GetLatestBlockhashResult) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.blockhash, cc.blockhashAt = bh, time.Now()
+}
+
+func (cc *chainCache) getGlobal() *types.GlobalAccount {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.global == nil || time.Since(cc.globalAt) > globalAccountTTL {
+		return nil
+	}
+	return cc.global
+}
+
+func (cc *chainCache) setGlobal(global *types.GlobalAccount) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.global, cc.globalAt = global, time.Now()
+}
+
+func (cc *chainCache) close() {
+	cc.stopOnce.Do(func() { close(cc.stop) })
+}
+
+// Prewarm fetches a blockhash and the global account now and keeps both
+// fresh in the background until Close, so a launch can build and sign
+// without waiting on the RPC.
+func (c *RPCClient) Prewarm(ctx context.Context) error {
+	if err := c.refreshCache(ctx); err != nil {
+		return err
+	}
+	c.cache.started.Do(func() { go c.refreshLoop() })
+	return nil
+}
+
+func (c *RPCClient) refreshLoop() {
+	ticker := time.NewTicker(blockhashRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.cache.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), blockhashRefresh)
+			if err := c.refreshCache(ctx); err != nil {
+				log.Printf("Cache refresh failed: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (c *RPCClient) refreshCache(ctx context.Context) error {
+	bh, err := c.fetchBlockhash(ctx)
+	if err != nil {
+		return err
+	}
+	c.cache.setBlockhash(bh)
+
+	if c.cache.getGlobal() == nil {
+		if _, err := c.getGlobalAccount(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"pf-launcher/internal/fill"
+	"pf-launcher/internal/offline"
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/rpcpool"
+	"pf-launcher/internal/txerrors"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const confirmPollInterval = 500 * time.Millisecond
+
+// confirmTransaction waits until tx is confirmed and returns its slot. It
+// gives up once the block height passes lastValidBlockHeight; pass zero for
+// durable nonce transactions, which only stop on ctx.
+func (c *RPCClient) confirmTransaction(ctx context.Context, tx *solana.Transaction, lastValidBlockHeight uint64) (uint64, error) {
+	sig := tx.Signatures[0]
+	ticker := time.NewTicker(confirmPollInterval)
+	defer ticker.Stop()
+
+	for {
+		statuses, err := rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (*rpc.GetSignatureStatusesResult, error) {
+			return client.GetSignatureStatuses(ctx, false, sig)
+		})
+		if err != nil && !retry.Classify(err).Retryable() {
+			return 0, fmt.Errorf("failed to get signature status: %w", err)
+		}
+
+		if err == nil && len(statuses.Value) > 0 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+			if status.Err != nil {
+				return 0, txerrors.FromMetaErr(status.Err, tx, nil)
+			}
+			if status.ConfirmationStatus == rpc.ConfirmationStatusConfirmed || status.ConfirmationStatus == rpc.ConfirmationStatusFinalized {
+				return status.Slot, nil
+			}
+		} else if err == nil && lastValidBlockHeight > 0 {
+			height, err := rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (uint64, error) {
+				return client.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+			})
+			if err == nil && height > lastValidBlockHeight {
+				return 0, fmt.Errorf("transaction %s expired at block height %d without confirming", sig, lastValidBlockHeight)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("waiting for confirmation of %s: %w", sig, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// TransactionStatus reports whether sig has landed, searching history, and
+// the decoded error if it landed and failed.
+func (c *RPCClient) TransactionStatus(ctx context.Context, sig solana.Signature) (bool, error) {
+	statuses, err := retry.Value(ctx, retry.Default, "get signature status", func(ctx context.Context) (*rpc.GetSignatureStatusesResult, error) {
+		return rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (*rpc.GetSignatureStatusesResult, error) {
+			return client.GetSignatureStatuses(ctx, true, sig)
+		})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get signature status after retries: %w", err)
+	}
+	if len(statuses.Value) == 0 || statuses.Value[0] == nil {
+		return false, nil
+	}
+	if statuses.Value[0].Err != nil {
+		return true, fmt.Errorf("transaction %s failed: %v", sig, statuses.Value[0].Err)
+	}
+	return true, nil
+}
+
+// ResolveLaunch settles whether a signed transaction landed. It waits while
+// the transaction could still land and returns false only once the block
+// height has passed lastValidBlockHeight without it showing up.
+func (c *RPCClient) ResolveLaunch(ctx context.Context, sig solana.Signature, lastValidBlockHeight uint64) (bool, error) {
+	ticker := time.NewTicker(confirmPollInterval)
+	defer ticker.Stop()
+	for {
+		landed, err := c.TransactionStatus(ctx, sig)
+		if landed || err != nil {
+			return landed, err
+		}
+
+		height, err := rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (uint64, error) {
+			return client.GetBlockHeight(ctx, rpc.CommitmentFinalized)
+		})
+		if err == nil && height > lastValidBlockHeight {
+			// Check once more now that it can no longer land.
+			return c.TransactionStatus(ctx, sig)
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("resolving %s: %w", sig, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// verifyFill computes the fill of the owner's trade of mint and compares it
+// with quote, warning when it was much worse.
+func (c *RPCClient) verifyFill(tx *solana.Transaction, meta *rpc.TransactionMeta, mint solana.PublicKey, quote *fill.Quote) (*fill.Fill, fill.Deviation, error) {
+	f, err := fill.FromTransaction(tx, meta, c.owner, mint)
+	if err != nil {
+		return nil, fill.Deviation{}, fmt.Errorf("failed to read fill: %w", err)
+	}
+	log.Printf("Fill: %s", f)
+
+	var deviation fill.Deviation
+	if quote != nil {
+		deviation = f.Compare(*quote, c.maxDeviationBps)
+		if deviation.Suspicious {
+			log.Printf("WARNING: %s: %s", f.Signature, deviation)
+		} else {
+			log.Printf("Fill %s", deviation)
+		}
+	}
+	return f, deviation, nil
+}
+
+// GetFill reads the fill of the owner's trade of mint in a confirmed
+// transaction.
+func (c *RPCClient) GetFill(ctx context.Context, sig solana.Signature, mint solana.PublicKey) (*fill.Fill, error) {
+	tx, meta, err := c.GetTransaction(ctx, sig)
+	if err != nil {
+		return nil, err
+	}
+	return fill.FromTransaction(tx, meta, c.owner, mint)
+}
+
+// ConfirmEnvelope waits for a submitted trade or launch to confirm and
+// checks its fill against the quote it was built with.
+func (c *RPCClient) ConfirmEnvelope(ctx context.Context, env *offline.Envelope) (*fill.Fill, fill.Deviation, error) {
+	tx, err := env.Tx()
+	if err != nil {
+		return nil, fill.Deviation{}, err
+	}
+	mint, err := solana.PublicKeyFromBase58(env.Mint)
+	if err != nil {
+		return nil, fill.Deviation{}, fmt.Errorf("invalid mint: %w", err)
+	}
+
+	// Durable nonce transactions do not expire by block height.
+	lastValid := env.LastValidBlockHeight
+	if env.NonceAccount != "" {
+		lastValid = 0
+	}
+	if _, err := c.confirmTransaction(ctx, tx, lastValid); err != nil {
+		return nil, fill.Deviation{}, err
+	}
+
+	confirmedTx, meta, err := c.GetTransaction(ctx, tx.Signatures[0])
+	if err != nil {
+		return nil, fill.Deviation{}, err
+	}
+	return c.verifyFill(confirmedTx, meta, mint, env.Quote)
+}
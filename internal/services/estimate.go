@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"pf-launcher/internal"
+	"pf-launcher/internal/inspect"
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/rpcpool"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type CostItem struct {
+	Name     string
+	Lamports uint64
+}
+
+// LaunchEstimate is the worst case cost of a launch next to the owner's
+// balance.
+type LaunchEstimate struct {
+	Items   []CostItem
+	Total   uint64
+	Balance uint64
+}
+
+// EstimateLaunch prices a launch with an initial buy of solAmount lamports:
+// rent for every account it creates, the buy with its protocol fee and
+// slippage allowance, and the transaction fee.
+func (c *RPCClient) EstimateLaunch(ctx context.Context, solAmount uint64) (*LaunchEstimate, error) {
+	estimate := &LaunchEstimate{}
+	accounts := []struct {
+		name string
+		size uint64
+	}{
+		{"mint account rent", internal.MINT_ACCOUNT_SIZE},
+		{"metadata account rent", internal.METADATA_ACCOUNT_SIZE},
+		{"bonding curve rent", internal.BONDING_CURVE_SIZE},
+		{"associated bonding curve rent", internal.TOKEN_ACCOUNT_SIZE},
+		{"token account rent", internal.TOKEN_ACCOUNT_SIZE},
+	}
+	for _, account := range accounts {
+		rent, err := c.getRentExemption(ctx, account.size)
+		if err != nil {
+			return nil, err
+		}
+		estimate.add(account.name, rent)
+	}
+
+	globalAccount, err := c.getGlobalAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get global account: %w", err)
+	}
+	tokens, err := globalAccount.GetInitialBuyPrice(solAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate initial buy amount: %w", err)
+	}
+	cost, err := globalAccount.GetInitialBuyCost(tokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate initial buy cost: %w", err)
+	}
+	fee := cost - cost*10000/(10000+globalAccount.FeeBasisPoints)
+	estimate.add("initial buy", cost-fee)
+	estimate.add(fmt.Sprintf("protocol fee (%d bps)", globalAccount.FeeBasisPoints), fee)
+	if limit := buySlippageLimit(solAmount); limit > cost {
+		estimate.add("slippage allowance", limit-cost)
+	}
+
+	// The owner and the mint sign.
+	estimate.add("transaction fee", 2*internal.LAMPORTS_PER_SIGNATURE)
+
+	balance, err := retry.Value(ctx, retry.Default, "get balance", func(ctx context.Context) (*rpc.GetBalanceResult, error) {
+		return rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (*rpc.GetBalanceResult, error) {
+			return client.GetBalance(ctx, c.owner, rpc.CommitmentConfirmed)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance after retries: %w", err)
+	}
+	estimate.Balance = balance.Value
+
+	return estimate, nil
+}
+
+func (c *RPCClient) getRentExemption(ctx context.Context, size uint64) (uint64, error) {
+	if rent, ok := c.cache.getRent(size); ok {
+		return rent, nil
+	}
+	rent, err := retry.Value(ctx, retry.Default, "get rent exemption", func(ctx context.Context) (uint64, error) {
+		return rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (uint64, error) {
+			return client.GetMinimumBalanceForRentExemption(ctx, size, rpc.CommitmentConfirmed)
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rent exemption for %d bytes: %w", size, err)
+	}
+	c.cache.setRent(size, rent)
+	return rent, nil
+}
+
+// rentTable is the rent for the account sizes the signing policy charges,
+// from the same RPC source as the launch estimate.
+func (c *RPCClient) rentTable(ctx context.Context) (inspect.Rent, error) {
+	rent := inspect.Rent{}
+	for _, size := range inspect.RentSizes {
+		lamports, err := c.getRentExemption(ctx, size)
+		if err != nil {
+			return nil, err
+		}
+		rent[size] = lamports
+	}
+	return rent, nil
+}
+
+func (e *LaunchEstimate) add(name string, lamports uint64) {
+	e.Items = append(e.Items, CostItem{Name: name, Lamports: lamports})
+	e.Total += lamports
+}
+
+// Check fails when the balance does not cover the estimate.
+func (e *LaunchEstimate) Check() error {
+	if e.Balance < e.Total {
+		return fmt.Errorf("balance of %s SOL does not cover the launch cost of %s SOL, %s SOL short", formatSol(e.Balance), formatSol(e.Total), formatSol(e.Total-e.Balance))
+	}
+	return nil
+}
+
+func (e *LaunchEstimate) String() string {
+	var b strings.Builder
+	for _, item := range e.Items {
+		fmt.Fprintf(&b, "  %-32s %14s SOL\n", item.Name, formatSol(item.Lamports))
+	}
+	fmt.Fprintf(&b, "  %-32s %14s SOL\n", "total", formatSol(e.Total))
+	fmt.Fprintf(&b, "  %-32s %14s SOL", "balance", formatSol(e.Balance))
+	return b.String()
+}
+
+func formatSol(lamports uint64) string {
+	return fmt.Sprintf("%.9f", float64(lamports)/float64(solana.LAMPORTS_PER_SOL))
+}
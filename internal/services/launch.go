@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pf-launcher/internal"
+	"pf-launcher/internal/fill"
+	"pf-launcher/internal/programs"
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+)
+
+// LaunchBuilder holds everything that belongs to a single launch, so several
+// launches can run on one client at the same time.
+type LaunchBuilder struct {
+	client *RPCClient
+
+	Mint                   *solana.Wallet
+	BondingCurve           solana.PublicKey
+	AssociatedBondingCurve solana.PublicKey
+	AssociatedTokenAccount solana.PublicKey
+	Instructions           []solana.Instruction
+	// Quote is what the initial buy was priced at.
+	Quote *fill.Quote
+}
+
+// NewLaunchBuilder generates a fresh mint and derives its accounts.
+func (c *RPCClient) NewLaunchBuilder() (*LaunchBuilder, error) {
+	mint := solana.NewWallet()
+	program := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
+
+	bondingCurve, _, err := programs.DeriveBondingCurve(mint.PublicKey(), program)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving bonding curve: %w", err)
+	}
+	assocBondingCurve, _, err := programs.DeriveAssociatedBondingCurve(mint.PublicKey(), bondingCurve)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving associated bonding curve: %w", err)
+	}
+	assocUser, _, err := programs.DeriveAssociatedTokenAccount(c.owner, mint.PublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("error deriving associated token account: %w", err)
+	}
+
+	return &LaunchBuilder{
+		client:                 c,
+		Mint:                   mint,
+		BondingCurve:           bondingCurve,
+		AssociatedBondingCurve: assocBondingCurve,
+		AssociatedTokenAccount: assocUser,
+	}, nil
+}
+
+// Build adds the create, ATA and initial buy instructions.
+func (b *LaunchBuilder) Build(ctx context.Context, metadata types.Metadata, metadataUri string, solAmount uint64) error {
+	createIx, err := b.client.AddCreateInstruction(ctx, b.Mint.PublicKey(), metadata, metadataUri)
+	if err != nil {
+		return fmt.Errorf("failed to add create instruction: %w", err)
+	}
+
+	createAssocIx := associatedtokenaccount.NewCreateInstruction(
+		b.client.owner,
+		b.client.owner,
+		b.Mint.PublicKey(),
+	).Build()
+
+	buyIx, quote, err := b.client.AddBuyInstruction(ctx, b.Mint.PublicKey(), solAmount)
+	if err != nil {
+		return fmt.Errorf("failed to add buy instruction: %w", err)
+	}
+
+	b.Instructions = []solana.Instruction{createIx, createAssocIx, buyIx}
+	b.Quote = quote
+	return nil
+}
+
+// signer returns the mint key for the mint and nil for anything else.
+func (b *LaunchBuilder) signer(key solana.PublicKey) *solana.PrivateKey {
+	if key.Equals(b.Mint.PublicKey()) {
+		return &b.Mint.PrivateKey
+	}
+	return nil
+}
+
+// LaunchTimings is how long each stage of a launch took.
+type LaunchTimings struct {
+	// Upload is filled in by the caller, since LaunchToken starts after it.
+	Upload  time.Duration
+	Build   time.Duration
+	Sign    time.Duration
+	Send    time.Duration
+	Confirm time.Duration
+}
+
+func (t LaunchTimings) String() string {
+	return fmt.Sprintf("upload %s, build %s, sign %s, send %s, confirm %s",
+		t.Upload.Round(time.Millisecond), t.Build.Round(time.Millisecond), t.Sign.Round(time.Millisecond),
+		t.Send.Round(time.Millisecond), t.Confirm.Round(time.Millisecond))
+}
+
+// LaunchResult describes a confirmed launch.
+type LaunchResult struct {
+	Mint                   solana.PublicKey
+	Signature              solana.Signature
+	Slot                   uint64
+	BondingCurve           solana.PublicKey
+	AssociatedTokenAccount solana.PublicKey
+	Quote                  *fill.Quote
+	// Fill, Deviation, TokensReceived, SolSpent and Fee stay empty when the
+	// confirmed transaction could not be read back.
+	Fill      *fill.Fill
+	Deviation fill.Deviation
+	// TokensReceived is the raw token amount that landed in the ATA.
+	TokensReceived uint64
+	// SolSpent is every lamport that left the owner, fees and rent included.
+	SolSpent uint64
+	Fee      uint64
+	Timings  LaunchTimings
+}
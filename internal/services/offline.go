@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"pf-launcher/internal"
+	"pf-launcher/internal/fill"
+	"pf-launcher/internal/offline"
+	"pf-launcher/internal/programs"
+	"pf-launcher/internal/rpcpool"
+	"pf-launcher/internal/types"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// BuildLaunchTransaction builds an unsigned launch transaction for the owner.
+// The freshly generated mint signs immediately, so only the owner's signature
+// is left for the offline machine.
+func (c *RPCClient) BuildLaunchTransaction(ctx context.Context, metadata types.Metadata, metadataUri string, solAmount uint64, nonceAccount solana.PublicKey) (*offline.Envelope, error) {
+	builder, err := c.NewLaunchBuilder()
+	if err != nil {
+		return nil, err
+	}
+	if err := builder.Build(ctx, metadata, metadataUri, solAmount); err != nil {
+		return nil, err
+	}
+
+	tx, bh, err := c.newTransaction(ctx, builder.Instructions, nonceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.guard(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.PartialSign(builder.signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction with mint: %w", err)
+	}
+
+	env, err := newEnvelope(offline.KindLaunch, tx, builder.Mint.PublicKey(), bh, nonceAccount)
+	if err != nil {
+		return nil, err
+	}
+	env.Quote = builder.Quote
+	if env.Rent, err = c.rentTable(ctx); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// BuildTradeTransaction builds an unsigned buy or sell against an existing
+// bonding curve. For buys amount is in lamports, for sells it is in raw
+// token units.
+func (c *RPCClient) BuildTradeTransaction(ctx context.Context, kind string, mint solana.PublicKey, amount uint64, nonceAccount solana.PublicKey) (*offline.Envelope, error) {
+	var (
+		instructions []solana.Instruction
+		quote        *fill.Quote
+	)
+	switch kind {
+	case offline.KindBuy:
+		buyIx, buyQuote, err := c.tradeBuyInstruction(ctx, mint, amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add buy instruction: %w", err)
+		}
+		instructions = []solana.Instruction{
+			programs.NewCreateIdempotentATAIx(c.owner, c.owner, mint),
+			buyIx,
+		}
+		quote = buyQuote
+	case offline.KindSell:
+		sellIx, sellQuote, err := c.tradeSellInstruction(ctx, mint, amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add sell instruction: %w", err)
+		}
+		instructions = []solana.Instruction{sellIx}
+		quote = sellQuote
+	default:
+		return nil, fmt.Errorf("unknown trade kind %q", kind)
+	}
+
+	tx, bh, err := c.newTransaction(ctx, instructions, nonceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.guard(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	env, err := newEnvelope(kind, tx, mint, bh, nonceAccount)
+	if err != nil {
+		return nil, err
+	}
+	env.Quote = quote
+	if env.Rent, err = c.rentTable(ctx); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// SubmitEnvelope checks that a signed envelope is complete and still valid
+// and sends it.
+func (c *RPCClient) SubmitEnvelope(ctx context.Context, env *offline.Envelope) (solana.Signature, error) {
+	tx, err := env.Tx()
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	if err := tx.VerifySignatures(); err != nil {
+		return solana.Signature{}, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	if env.NonceAccount != "" {
+		if err := c.checkNonce(ctx, tx, env.NonceAccount); err != nil {
+			return solana.Signature{}, err
+		}
+	} else {
+		valid, err := rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (*rpc.IsValidBlockhashResult, error) {
+			return client.IsBlockhashValid(ctx, tx.Message.RecentBlockhash, rpc.CommitmentProcessed)
+		})
+		if err != nil {
+			return solana.Signature{}, fmt.Errorf("failed to check blockhash: %w", err)
+		}
+		if !valid.Value {
+			return solana.Signature{}, fmt.Errorf("blockhash %s has expired, rebuild the transaction", tx.Message.RecentBlockhash)
+		}
+	}
+
+	return c.sendTransaction(ctx, tx, nil)
+}
+
+func (c *RPCClient) checkNonce(ctx context.Context, tx *solana.Transaction, nonceAccount string) error {
+	nonceKey, err := solana.PublicKeyFromBase58(nonceAccount)
+	if err != nil {
+		return fmt.Errorf("invalid nonce account: %w", err)
+	}
+
+	if len(tx.Message.Instructions) == 0 {
+		return fmt.Errorf("transaction has no instructions")
+	}
+	first := tx.Message.Instructions[0]
+	programID, err := tx.Message.Program(first.ProgramIDIndex)
+	if err != nil || !programID.Equals(solana.SystemProgramID) {
+		return fmt.Errorf("first instruction is not a nonce advance")
+	}
+	if len(first.Accounts) == 0 {
+		return fmt.Errorf("first instruction is not a nonce advance")
+	}
+	accounts, err := first.ResolveInstructionAccounts(&tx.Message)
+	if err != nil || !accounts[0].PublicKey.Equals(nonceKey) {
+		return fmt.Errorf("nonce advance does not use nonce account %s", nonceKey)
+	}
+
+	nonce, err := c.getNonceAccount(ctx, nonceKey)
+	if err != nil {
+		return err
+	}
+	if solana.Hash(nonce.Nonce) != tx.Message.RecentBlockhash {
+		return fmt.Errorf("nonce has advanced since the transaction was built, rebuild the transaction")
+	}
+
+	return nil
+}
+
+func (c *RPCClient) tradeBuyInstruction(ctx context.Context, mint solana.PublicKey, solAmount uint64) (*solana.GenericInstruction, *fill.Quote, error) {
+	globalAccount, err := c.getGlobalAccount(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get global account: %w", err)
+	}
+
+	curve, err := c.getBondingCurve(ctx, mint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get bonding curve: %w", err)
+	}
+
+	buyAmount, err := curve.GetBuyPrice(solAmount, globalAccount.FeeBasisPoints)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to calculate buy amount: %w", err)
+	}
+	lamportsWithBuffer := buySlippageLimit(solAmount)
+
+	program := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
+	bondingCurve, _, _ := programs.DeriveBondingCurve(mint, program)
+	assocBondingCurve, _, _ := programs.DeriveAssociatedBondingCurve(mint, bondingCurve)
+	assocUser, _, _ := programs.DeriveAssociatedTokenAccount(c.owner, mint)
+	eventAuthority, _ := solana.PublicKeyFromBase58(internal.EVENT_AUTHORITY)
+	creatorVault, _, _ := programs.DeriveCreatorVault(curve.Creator, program)
+
+	buyIx := programs.NewBuyIx(
+		buyAmount,
+		lamportsWithBuffer,
+		globalAccount.FeeRecipient,
+		mint,
+		assocBondingCurve,
+		assocUser,
+		c.owner,
+		solana.SystemProgramID,
+		solana.TokenProgramID,
+		creatorVault,
+		eventAuthority,
+	)
+	return buyIx, &fill.Quote{IsBuy: true, Tokens: buyAmount, Sol: solAmount, Limit: lamportsWithBuffer}, nil
+}
+
+func (c *RPCClient) tradeSellInstruction(ctx context.Context, mint solana.PublicKey, tokenAmount uint64) (*solana.GenericInstruction, *fill.Quote, error) {
+	globalAccount, err := c.getGlobalAccount(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get global account: %w", err)
+	}
+
+	curve, err := c.getBondingCurve(ctx, mint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get bonding curve: %w", err)
+	}
+
+	solOutput, err := curve.GetSellPrice(tokenAmount, globalAccount.FeeBasisPoints)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to calculate sell output: %w", err)
+	}
+	minSolOutput := sellSlippageLimit(solOutput)
+
+	program := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
+	bondingCurve, _, _ := programs.DeriveBondingCurve(mint, program)
+	assocBondingCurve, _, _ := programs.DeriveAssociatedBondingCurve(mint, bondingCurve)
+	assocUser, _, _ := programs.DeriveAssociatedTokenAccount(c.owner, mint)
+	eventAuthority, _ := solana.PublicKeyFromBase58(internal.EVENT_AUTHORITY)
+	creatorVault, _, _ := programs.DeriveCreatorVault(curve.Creator, program)
+
+	sellIx := programs.NewSellIx(
+		tokenAmount,
+		minSolOutput,
+		globalAccount.FeeRecipient,
+		mint,
+		assocBondingCurve,
+		assocUser,
+		c.owner,
+		solana.SystemProgramID,
+		creatorVault,
+		solana.TokenProgramID,
+		eventAuthority,
+	)
+	return sellIx, &fill.Quote{Tokens: tokenAmount, Sol: solOutput, Limit: minSolOutput}, nil
+}
+
+func newEnvelope(kind string, tx *solana.Transaction, mint solana.PublicKey, bh *rpc.GetLatestBlockhashResult, nonceAccount solana.PublicKey) (*offline.Envelope, error) {
+	env, err := offline.NewEnvelope(kind, tx, mint)
+	if err != nil {
+		return nil, err
+	}
+	if bh != nil {
+		env.LastValidBlockHeight = bh.Value.LastValidBlockHeight
+	}
+	if !nonceAccount.IsZero() {
+		env.NonceAccount = nonceAccount.String()
+	}
+	return env, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// LaunchStage is how far a launch got.
+type LaunchStage string
+
+const (
+	StageBuild   LaunchStage = "build"
+	StageSign    LaunchStage = "sign"
+	StageSend    LaunchStage = "send"
+	StageConfirm LaunchStage = "confirm"
+)
+
+// LaunchCheckpoint is reported when a launch enters the send and confirm
+// stages, so callers can persist the signature before it goes out.
+type LaunchCheckpoint struct {
+	Stage                LaunchStage
+	Mint                 solana.PublicKey
+	Signature            solana.Signature
+	LastValidBlockHeight uint64
+}
+
+// LaunchError records the stage a launch failed or was cancelled in, with
+// the mint and signature when they were already known.
+type LaunchError struct {
+	Stage     LaunchStage
+	Mint      solana.PublicKey
+	Signature solana.Signature
+	Err       error
+}
+
+func (e *LaunchError) Error() string {
+	return fmt.Sprintf("launch failed during %s: %v", e.Stage, e.Err)
+}
+
+func (e *LaunchError) Unwrap() error {
+	return e.Err
+}
+
+func (e *LaunchError) wrap(err error) error {
+	e.Err = err
+	return e
+}
@@ -2,41 +2,60 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/near/borsh-go"
 
 	"pf-launcher/internal"
+	"pf-launcher/internal/fill"
+	"pf-launcher/internal/inspect"
 	"pf-launcher/internal/programs"
+	"pf-launcher/internal/ratelimit"
+	"pf-launcher/internal/retry"
+	"pf-launcher/internal/rpcpool"
+	"pf-launcher/internal/txerrors"
 	"pf-launcher/internal/types"
 
+	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
-	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 	"github.com/joho/godotenv"
 )
 
 type RPCClient struct {
-	rpcClient *rpc.Client
-	user      *solana.Wallet
-	mint      *solana.Wallet
+	pool   *rpcpool.Pool
+	user   *solana.Wallet
+	owner  solana.PublicKey
+	policy *inspect.Policy
+	// maxDeviationBps is how much worse than quoted a fill may be.
+	maxDeviationBps int64
+	cache           *chainCache
 }
 
 func NewRPCClient(privateKey string) (*RPCClient, error) {
-	if err := godotenv.Load(); err != nil {
-		return nil, fmt.Errorf("error loading .env file: %v", err)
+	pool, err := newPool()
+	if err != nil {
+		return nil, err
 	}
 
-	rpcURL := os.Getenv("RPC")
-	if rpcURL == "" {
-		return nil, fmt.Errorf("RPC URL not found in .env file")
+	policy, err := inspect.PolicyFromEnv()
+	if err != nil {
+		return nil, err
 	}
 
-	rpcClient := rpc.New(rpcURL)
+	maxDeviationBps, err := fill.MaxDeviationFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
 	user, err := solana.WalletFromPrivateKeyBase58(privateKey)
 	if err != nil {
@@ -44,115 +63,374 @@ func NewRPCClient(privateKey string) (*RPCClient, error) {
 	}
 
 	return &RPCClient{
-		rpcClient: rpcClient,
-		user:      user,
+		pool:            pool,
+		user:            user,
+		owner:           user.PublicKey(),
+		policy:          policy,
+		maxDeviationBps: maxDeviationBps,
+		cache:           newChainCache(),
 	}, nil
 }
 
-func (c *RPCClient) LaunchToken(metadata types.Metadata, metadataUri string, solAmount uint64) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// NewWatchOnlyRPCClient creates a client that can build transactions for
+// owner but cannot sign them, for use with the offline signing workflow.
+// An empty owner gives a client that can only read.
+func NewWatchOnlyRPCClient(owner string) (*RPCClient, error) {
+	pool, err := newPool()
+	if err != nil {
+		return nil, err
+	}
 
-	createIx, err := c.AddCreateInstruction(metadata, metadataUri)
+	var ownerKey solana.PublicKey
+	if owner != "" {
+		ownerKey, err = solana.PublicKeyFromBase58(owner)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing owner public key: %v", err)
+		}
+	}
+
+	policy, err := inspect.PolicyFromEnv()
 	if err != nil {
-		return fmt.Errorf("failed to add create instruction: %w", err)
+		return nil, err
+	}
+
+	maxDeviationBps, err := fill.MaxDeviationFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RPCClient{
+		pool:            pool,
+		owner:           ownerKey,
+		policy:          policy,
+		maxDeviationBps: maxDeviationBps,
+		cache:           newChainCache(),
+	}, nil
+}
+
+// newPool builds the endpoint pool from RPC, a comma separated list of URLs.
+// RPC_MAX_SLOT_LAG sets how far an endpoint may fall behind the others.
+// RPC_RATE_LIMITS sets requests per second per method class for every
+// endpoint, e.g. "send=5,read=40,gpa=1", and a URL fragment such as
+// "https://rpc.example.com#read=100" overrides it for one endpoint.
+func newPool() (*rpcpool.Pool, error) {
+	if err := godotenv.Load(); err != nil {
+		return nil, fmt.Errorf("error loading .env file: %v", err)
 	}
 
-	createAssocIx := associatedtokenaccount.NewCreateInstruction(
-		c.user.PublicKey(),
-		c.user.PublicKey(),
-		c.mint.PublicKey(),
-	).Build()
+	rpcURLs := os.Getenv("RPC")
+	if rpcURLs == "" {
+		return nil, fmt.Errorf("RPC URL not found in .env file")
+	}
 
-	buyIx, err := c.AddBuyInstruction(c.mint.PublicKey(), solAmount)
+	maxSlotLag := uint64(50)
+	if lag := os.Getenv("RPC_MAX_SLOT_LAG"); lag != "" {
+		parsed, err := strconv.ParseUint(lag, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RPC_MAX_SLOT_LAG %q: %v", lag, err)
+		}
+		maxSlotLag = parsed
+	}
+
+	defaultLimits, err := ratelimit.ParseLimits(os.Getenv("RPC_RATE_LIMITS"), ratelimit.DefaultLimits)
 	if err != nil {
-		return fmt.Errorf("failed to add buy instruction: %w", err)
+		return nil, fmt.Errorf("invalid RPC_RATE_LIMITS: %v", err)
 	}
 
-	var bh *rpc.GetLatestBlockhashResult
-	for i := 0; i < 3; i++ {
-		bh, err = c.rpcClient.GetLatestBlockhash(ctx, rpc.CommitmentProcessed)
-		if err == nil {
-			break
+	var endpoints []*rpcpool.Endpoint
+	for _, rpcURL := range strings.Split(rpcURLs, ",") {
+		rpcURL, limitSpec, _ := strings.Cut(strings.TrimSpace(rpcURL), "#")
+		if rpcURL == "" {
+			continue
+		}
+
+		limits, err := ratelimit.ParseLimits(limitSpec, defaultLimits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limits for %s: %v", rpcURL, err)
+		}
+
+		limiter, err := ratelimit.ForEndpoint(rpcURL, limits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limits: %v", err)
+		}
+		httpClient := retry.NewStatusClient(&http.Client{
+			Timeout:   5 * time.Minute,
+			Transport: &ratelimit.Transport{Limiter: limiter},
+		})
+		client := rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(rpcURL, &jsonrpc.RPCClientOpts{
+			HTTPClient: httpClient,
+		}))
+		endpoints = append(endpoints, rpcpool.NewEndpoint(rpcURL, client))
+	}
+
+	return rpcpool.New(endpoints, maxSlotLag, 10*time.Second)
+}
+
+// Close stops the background endpoint health checks and cache refresh.
+func (c *RPCClient) Close() {
+	c.cache.close()
+	c.pool.Close()
+}
+
+// RateLimitStats reports queue depth and throttle counts per endpoint and
+// method class.
+func (c *RPCClient) RateLimitStats() map[string]map[string]ratelimit.Stats {
+	stats := make(map[string]map[string]ratelimit.Stats)
+	for _, endpoint := range c.pool.Stats() {
+		if limiter, ok := ratelimit.Lookup(endpoint.URL); ok {
+			stats[endpoint.URL] = limiter.Stats()
 		}
-		log.Printf("Attempt %d: Failed to get blockhash: %v", i+1, err)
-		time.Sleep(time.Second * time.Duration(i+1))
 	}
+	return stats
+}
+
+// EndpointStats reports the health of every configured RPC endpoint.
+func (c *RPCClient) EndpointStats() []rpcpool.EndpointStats {
+	return c.pool.Stats()
+}
+
+// LaunchToken creates the token with an initial buy, waits for confirmation
+// and reports what the launch actually cost. On failure the error is a
+// *LaunchError saying how far the launch got. checkpoint, if set, is called
+// once the transaction is signed and again once it is sent.
+func (c *RPCClient) LaunchToken(ctx context.Context, metadata types.Metadata, metadataUri string, solAmount uint64, checkpoint func(LaunchCheckpoint)) (*LaunchResult, error) {
+	if c.user == nil {
+		return nil, fmt.Errorf("client has no private key, use the offline signing workflow")
+	}
+
+	progress := &LaunchError{Stage: StageBuild}
+	start := time.Now()
+	builder, err := c.NewLaunchBuilder()
 	if err != nil {
-		return fmt.Errorf("failed to get blockhash after retries: %w", err)
+		return nil, progress.wrap(err)
+	}
+	progress.Mint = builder.Mint.PublicKey()
+	result := &LaunchResult{
+		Mint:                   builder.Mint.PublicKey(),
+		BondingCurve:           builder.BondingCurve,
+		AssociatedTokenAccount: builder.AssociatedTokenAccount,
 	}
 
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{createIx, createAssocIx, buyIx},
-		bh.Value.Blockhash,
-		solana.TransactionPayer(c.user.PublicKey()),
-	)
+	if err := builder.Build(ctx, metadata, metadataUri, solAmount); err != nil {
+		return nil, progress.wrap(err)
+	}
+	result.Quote = builder.Quote
+
+	tx, bh, err := c.newTransaction(ctx, builder.Instructions, solana.PublicKey{})
 	if err != nil {
-		return fmt.Errorf("failed to create transaction: %w", err)
+		return nil, progress.wrap(err)
+	}
+	result.Timings.Build = time.Since(start)
+
+	log.Printf("mint: %+v", builder.Mint.PublicKey())
+	progress.Stage = StageSign
+	start = time.Now()
+	if err := c.guard(ctx, tx); err != nil {
+		return nil, progress.wrap(err)
 	}
 
-	log.Printf("mint: %+v", c.mint.PublicKey())
 	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
 		if key.Equals(c.user.PublicKey()) {
 			return &c.user.PrivateKey
 		}
-		if key.Equals(c.mint.PublicKey()) {
-			return &c.mint.PrivateKey
-		}
-		return nil
+		return builder.signer(key)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to sign transaction: %w", err)
+		return nil, progress.wrap(fmt.Errorf("failed to sign transaction: %w", err))
+	}
+	result.Timings.Sign = time.Since(start)
+
+	// The signature is known before sending, so an interrupted send can
+	// still be looked up.
+	progress.Stage = StageSend
+	progress.Signature = tx.Signatures[0]
+	result.Signature = tx.Signatures[0]
+	notify := func(stage LaunchStage) {
+		if checkpoint != nil {
+			checkpoint(LaunchCheckpoint{
+				Stage:                stage,
+				Mint:                 result.Mint,
+				Signature:            result.Signature,
+				LastValidBlockHeight: bh.Value.LastValidBlockHeight,
+			})
+		}
+	}
+	notify(StageSend)
+	start = time.Now()
+	sig, err := c.sendTransaction(ctx, tx, &bh.Context.Slot)
+	if err != nil {
+		return nil, progress.wrap(err)
 	}
+	result.Timings.Send = time.Since(start)
+	log.Printf("Create & Buy instructions sent - signature: %s", sig.String())
 
-	// Retry sending transaction
-	var sig solana.Signature
-	for i := 0; i < 3; i++ {
-		sig, err = c.rpcClient.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
-			SkipPreflight:       false,
-			PreflightCommitment: rpc.CommitmentProcessed,
-			MinContextSlot:      &bh.Context.Slot,
-		})
-		if err == nil {
-			break
+	progress.Stage = StageConfirm
+	notify(StageConfirm)
+	start = time.Now()
+	result.Slot, err = c.confirmTransaction(ctx, tx, bh.Value.LastValidBlockHeight)
+	if err != nil {
+		return nil, progress.wrap(err)
+	}
+	result.Timings.Confirm = time.Since(start)
+
+	// The token is out at this point. Reading the fill back is best effort,
+	// the transaction is often not indexed yet right after confirmation.
+	if err := c.readFill(ctx, result); err != nil {
+		log.Printf("WARNING: launch %s confirmed but its fill could not be verified, check it later with `fill -sig %s -mint %s`: %v", result.Mint, result.Signature, result.Mint, err)
+	}
+	return result, nil
+}
+
+func (c *RPCClient) readFill(ctx context.Context, result *LaunchResult) error {
+	confirmedTx, meta, err := c.GetTransaction(ctx, result.Signature)
+	if err != nil {
+		return err
+	}
+	result.Fee = meta.Fee
+	result.Fill, result.Deviation, err = c.verifyFill(confirmedTx, meta, result.Mint, result.Quote)
+	if err != nil {
+		return err
+	}
+	result.TokensReceived = result.Fill.Tokens
+	if result.Fill.SolDelta < 0 {
+		result.SolSpent = uint64(-result.Fill.SolDelta)
+	}
+	return nil
+}
+
+// guard logs what tx does and refuses it if it breaks the signing policy.
+func (c *RPCClient) guard(ctx context.Context, tx *solana.Transaction) error {
+	rent, err := c.rentTable(ctx)
+	if err != nil {
+		return err
+	}
+	report, err := c.policy.Guard(tx, rent)
+	if report != nil {
+		log.Printf("Transaction summary:\n%s", report)
+	}
+	if err != nil {
+		return fmt.Errorf("refusing to sign: %w", err)
+	}
+	return nil
+}
+
+// newTransaction builds an unsigned transaction paid by the owner. When
+// nonceAccount is set the transaction uses the durable nonce stored in it
+// instead of a recent blockhash, and the returned blockhash result is nil.
+func (c *RPCClient) newTransaction(ctx context.Context, instructions []solana.Instruction, nonceAccount solana.PublicKey) (*solana.Transaction, *rpc.GetLatestBlockhashResult, error) {
+	var (
+		bh        *rpc.GetLatestBlockhashResult
+		blockhash solana.Hash
+		err       error
+	)
+
+	if nonceAccount.IsZero() {
+		if bh = c.cache.getBlockhash(); bh == nil {
+			bh, err = c.fetchBlockhash(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
 		}
-		log.Printf("Attempt %d: Failed to send transaction: %v", i+1, err)
-		// Try to get more detailed error information
-		if rpcErr, ok := err.(*jsonrpc.RPCError); ok {
-			log.Printf("RPC Error details - Code: %d, Message: %s", rpcErr.Code, rpcErr.Message)
+		blockhash = bh.Value.Blockhash
+	} else {
+		nonce, err := c.getNonceAccount(ctx, nonceAccount)
+		if err != nil {
+			return nil, nil, err
 		}
-		time.Sleep(time.Second * time.Duration(i+1))
+		blockhash = solana.Hash(nonce.Nonce)
+
+		advanceIx := system.NewAdvanceNonceAccountInstruction(
+			nonceAccount,
+			solana.SysVarRecentBlockHashesPubkey,
+			c.owner,
+		).Build()
+		instructions = append([]solana.Instruction{advanceIx}, instructions...)
 	}
+
+	tx, err := solana.NewTransaction(
+		instructions,
+		blockhash,
+		solana.TransactionPayer(c.owner),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to send transaction after retries: %w", err)
+		return nil, nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	log.Printf("Create & Buy instructions sent - signature: %s", sig.String())
-	return nil
+	return tx, bh, nil
 }
 
-func (c *RPCClient) AddBuyInstruction(mint solana.PublicKey, solAmount uint64) (*solana.GenericInstruction, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+func (c *RPCClient) fetchBlockhash(ctx context.Context) (*rpc.GetLatestBlockhashResult, error) {
+	bh, err := retry.Value(ctx, retry.Default, "get blockhash", func(ctx context.Context) (*rpc.GetLatestBlockhashResult, error) {
+		return rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (*rpc.GetLatestBlockhashResult, error) {
+			return client.GetLatestBlockhash(ctx, rpc.CommitmentProcessed)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockhash after retries: %w", err)
+	}
+	return bh, nil
+}
 
+func (c *RPCClient) sendTransaction(ctx context.Context, tx *solana.Transaction, minContextSlot *uint64) (solana.Signature, error) {
+	sig, err := retry.Value(ctx, retry.Send, "send transaction", func(ctx context.Context) (solana.Signature, error) {
+		// Send through every healthy endpoint at once, first success wins.
+		return rpcpool.Broadcast(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (solana.Signature, error) {
+			sig, err := client.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+				SkipPreflight:       false,
+				PreflightCommitment: rpc.CommitmentProcessed,
+				MinContextSlot:      minContextSlot,
+			})
+			if err == nil {
+				return sig, nil
+			}
+			// Try to get more detailed error information
+			if txErr := txerrors.FromRPCError(err, tx); txErr != nil {
+				// An earlier attempt or another endpoint already landed
+				// this very transaction, so confirm it.
+				if errors.Is(txErr, txerrors.ErrAlreadyProcessed) {
+					log.Printf("Transaction %s was already processed", tx.Signatures[0])
+					return tx.Signatures[0], nil
+				}
+				log.Printf("Simulation failed - %v", txErr)
+				return sig, txErr
+			}
+			if rpcErr, ok := err.(*jsonrpc.RPCError); ok {
+				log.Printf("RPC Error details - Code: %d, Message: %s", rpcErr.Code, rpcErr.Message)
+			}
+			return sig, err
+		})
+	})
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("failed to send transaction after retries: %w", err)
+	}
+
+	return sig, nil
+}
+
+// AddBuyInstruction builds the initial buy for a mint created in the same
+// transaction, priced from the global account's initial reserves.
+func (c *RPCClient) AddBuyInstruction(ctx context.Context, mint solana.PublicKey, solAmount uint64) (*solana.GenericInstruction, *fill.Quote, error) {
 	globalAccount, err := c.getGlobalAccount(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get global account: %w", err)
+		return nil, nil, fmt.Errorf("failed to get global account: %w", err)
 	}
 
 	buyAmount, err := globalAccount.GetInitialBuyPrice(solAmount)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate initial buy amount: %w", err)
+		return nil, nil, fmt.Errorf("failed to calculate initial buy amount: %w", err)
 	}
-	slippagePercent := 10.0
-	lamportsWithBuffer := uint64(float64(solAmount) * (1 + slippagePercent/100))
+	cost, err := globalAccount.GetInitialBuyCost(buyAmount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to calculate initial buy cost: %w", err)
+	}
+	lamportsWithBuffer := buySlippageLimit(solAmount)
 
 	program := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
 	bondingCurve, _, _ := programs.DeriveBondingCurve(mint, program)
 	assocBondingCurve, _, _ := programs.DeriveAssociatedBondingCurve(mint, bondingCurve)
-	assocUser, _, _ := programs.DeriveAssociatedTokenAccount(c.user.PublicKey(), mint)
-	eventAuthority, _ := solana.PublicKeyFromBase58("Ce6TQqeHC9p8KetsN6JsjHK7UTZk7nasjjnr7XxXp9F1")
-	creatorVault, _, _ := programs.DeriveCreatorVault(c.user.PublicKey(), program)
+	assocUser, _, _ := programs.DeriveAssociatedTokenAccount(c.owner, mint)
+	eventAuthority, _ := solana.PublicKeyFromBase58(internal.EVENT_AUTHORITY)
+	creatorVault, _, _ := programs.DeriveCreatorVault(c.owner, program)
 
 	buyIx := programs.NewBuyIx(
 		buyAmount,
@@ -161,7 +439,7 @@ func (c *RPCClient) AddBuyInstruction(mint solana.PublicKey, solAmount uint64) (
 		mint,
 		assocBondingCurve,
 		assocUser,
-		c.user.PublicKey(),
+		c.owner,
 		solana.SystemProgramID,
 		solana.TokenProgramID,
 		creatorVault,
@@ -170,62 +448,152 @@ func (c *RPCClient) AddBuyInstruction(mint solana.PublicKey, solAmount uint64) (
 
 	log.Printf("Buy instruction data - amount: %d, max_sol_cost: %d", buyAmount, lamportsWithBuffer)
 
-	return buyIx, nil
+	return buyIx, &fill.Quote{IsBuy: true, Tokens: buyAmount, Sol: cost, Limit: lamportsWithBuffer}, nil
 }
 
-func (c *RPCClient) AddCreateInstruction(metadata types.Metadata, metadataUri string) (*solana.GenericInstruction, error) {
-	c.mint = solana.NewWallet()
+// sellSlippageLimit is the min SOL output of a sell, 10% below the quoted
+// output.
+func sellSlippageLimit(solOutput uint64) uint64 {
+	slippagePercent := 10.0
+	return uint64(float64(solOutput) * (1 - slippagePercent/100))
+}
 
-	createIx := programs.NewCreateIx(
-		c.mint.PublicKey(),
-		c.user.PublicKey(),
-		types.CreateData{
-			Name:    metadata.Name,
-			Symbol:  metadata.Symbol,
-			Uri:     metadataUri,
-			Creator: c.user.PublicKey(),
-		},
-	)
+// buySlippageLimit is the max SOL cost of the initial buy, 10% above the
+// amount asked for.
+func buySlippageLimit(solAmount uint64) uint64 {
+	slippagePercent := 10.0
+	return uint64(float64(solAmount) * (1 + slippagePercent/100))
+}
+
+func (c *RPCClient) AddCreateInstruction(ctx context.Context, mint solana.PublicKey, metadata types.Metadata, metadataUri string) (*solana.GenericInstruction, error) {
+	data := types.CreateData{
+		Name:    metadata.Name,
+		Symbol:  metadata.Symbol,
+		Uri:     metadataUri,
+		Creator: c.owner,
+	}
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	return programs.NewCreateIx(mint, c.owner, data), nil
+}
 
-	return createIx, nil
+// GetTransaction fetches a confirmed transaction together with its metadata.
+func (c *RPCClient) GetTransaction(ctx context.Context, sig solana.Signature) (*solana.Transaction, *rpc.TransactionMeta, error) {
+	maxVersion := uint64(0)
+	result, err := retry.Value(ctx, retry.Default, "get transaction", func(ctx context.Context) (*rpc.GetTransactionResult, error) {
+		return rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (*rpc.GetTransactionResult, error) {
+			return client.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+				Encoding:                       solana.EncodingBase64,
+				Commitment:                     rpc.CommitmentConfirmed,
+				MaxSupportedTransactionVersion: &maxVersion,
+			})
+		})
+	})
+	if errors.Is(err, rpc.ErrNotFound) {
+		return nil, nil, fmt.Errorf("transaction %s not found", sig)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting transaction after retries: %w", err)
+	}
+
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decoding transaction: %w", err)
+	}
+
+	return tx, result.Meta, nil
 }
 
+// getGlobalAccount returns the global account, reusing a cached copy for up
+// to globalAccountTTL.
 func (c *RPCClient) getGlobalAccount(ctx context.Context) (*types.GlobalAccount, error) {
+	if global := c.cache.getGlobal(); global != nil {
+		return global, nil
+	}
+
 	programID := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
 	globalAccount, _, err := programs.DeriveGlobal(programID)
 	if err != nil {
 		return nil, fmt.Errorf("error deriving global account: %w", err)
 	}
 
-	var accountInfo *rpc.GetAccountInfoResult
-	for i := 0; i < 3; i++ {
-		accountInfo, err = c.rpcClient.GetAccountInfoWithOpts(ctx, globalAccount, &rpc.GetAccountInfoOpts{
-			Commitment: rpc.CommitmentConfirmed,
+	rawData, err := c.getAccountData(ctx, globalAccount)
+	if err != nil {
+		return nil, fmt.Errorf("global account: %w", err)
+	}
+
+	var globalData types.GlobalAccount
+	if err := borsh.Deserialize(&globalData, rawData); err != nil {
+		log.Printf("Failed to deserialize global account data: %v", err)
+		return nil, fmt.Errorf("error deserializing global account data: %w", err)
+	}
+
+	c.cache.setGlobal(&globalData)
+	return &globalData, nil
+}
+
+func (c *RPCClient) getBondingCurve(ctx context.Context, mint solana.PublicKey) (*types.BondingCurveAccount, error) {
+	programID := solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)
+	bondingCurve, _, err := programs.DeriveBondingCurve(mint, programID)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving bonding curve: %w", err)
+	}
+
+	rawData, err := c.getAccountData(ctx, bondingCurve)
+	if err != nil {
+		return nil, fmt.Errorf("bonding curve: %w", err)
+	}
+
+	var curveData types.BondingCurveAccount
+	if err := borsh.Deserialize(&curveData, rawData); err != nil {
+		return nil, fmt.Errorf("error deserializing bonding curve data: %w", err)
+	}
+
+	return &curveData, nil
+}
+
+func (c *RPCClient) getNonceAccount(ctx context.Context, nonceAccount solana.PublicKey) (*system.NonceAccount, error) {
+	rawData, err := c.getAccountData(ctx, nonceAccount)
+	if err != nil {
+		return nil, fmt.Errorf("nonce account: %w", err)
+	}
+
+	var nonce system.NonceAccount
+	if err := bin.NewBinDecoder(rawData).Decode(&nonce); err != nil {
+		return nil, fmt.Errorf("error deserializing nonce account data: %w", err)
+	}
+	if nonce.State != 1 {
+		return nil, fmt.Errorf("nonce account %s is not initialized", nonceAccount)
+	}
+
+	return &nonce, nil
+}
+
+func (c *RPCClient) getAccountData(ctx context.Context, account solana.PublicKey) ([]byte, error) {
+	accountInfo, err := retry.Value(ctx, retry.Default, "get account info", func(ctx context.Context) (*rpc.GetAccountInfoResult, error) {
+		return rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (*rpc.GetAccountInfoResult, error) {
+			return client.GetAccountInfoWithOpts(ctx, account, &rpc.GetAccountInfoOpts{
+				Commitment: rpc.CommitmentConfirmed,
+			})
 		})
-		if err == nil {
-			break
-		}
-		log.Printf("Attempt %d: Failed to get account info: %v", i+1, err)
-		time.Sleep(time.Second * time.Duration(i+1))
+	})
+	if errors.Is(err, rpc.ErrNotFound) {
+		return nil, fmt.Errorf("account %s not found", account)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error getting account info after retries: %w", err)
 	}
 
-	if accountInfo == nil {
-		return nil, fmt.Errorf("global account not found")
+	if accountInfo == nil || accountInfo.Value == nil {
+		return nil, fmt.Errorf("account %s not found", account)
 	}
 
 	rawData := accountInfo.Value.Data.GetBinary()
 	if len(rawData) == 0 {
-		return nil, fmt.Errorf("global account is empty")
-	}
-
-	var globalData types.GlobalAccount
-	if err := borsh.Deserialize(&globalData, rawData); err != nil {
-		log.Printf("Failed to deserialize global account data: %v", err)
-		return nil, fmt.Errorf("error deserializing global account data: %w", err)
+		return nil, fmt.Errorf("account %s is empty", account)
 	}
 
-	return &globalData, nil
+	return rawData, nil
 }
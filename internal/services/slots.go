@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"pf-launcher/internal/rpcpool"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+// wsEndpoint returns RPC_WS, or the first RPC URL with a websocket scheme.
+func wsEndpoint() string {
+	if url := os.Getenv("RPC_WS"); url != "" {
+		return url
+	}
+	url, _, _ := strings.Cut(os.Getenv("RPC"), ",")
+	url, _, _ = strings.Cut(strings.TrimSpace(url), "#")
+	if rest, ok := strings.CutPrefix(url, "https://"); ok {
+		return "wss://" + rest
+	}
+	if rest, ok := strings.CutPrefix(url, "http://"); ok {
+		return "ws://" + rest
+	}
+	return url
+}
+
+// WaitForSlot blocks until the cluster has processed slot, following slot
+// notifications. It polls getSlot instead when the websocket is unavailable.
+func (c *RPCClient) WaitForSlot(ctx context.Context, slot uint64) error {
+	client, err := ws.Connect(ctx, wsEndpoint())
+	if err != nil {
+		log.Printf("Slot subscription unavailable, polling instead: %v", err)
+		return c.pollForSlot(ctx, slot)
+	}
+	defer client.Close()
+
+	sub, err := client.SlotSubscribe()
+	if err != nil {
+		log.Printf("Slot subscription failed, polling instead: %v", err)
+		return c.pollForSlot(ctx, slot)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		result, err := sub.Recv(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Slot subscription dropped, polling instead: %v", err)
+			return c.pollForSlot(ctx, slot)
+		}
+		if result.Slot >= slot {
+			return nil
+		}
+	}
+}
+
+func (c *RPCClient) pollForSlot(ctx context.Context, slot uint64) error {
+	ticker := time.NewTicker(confirmPollInterval)
+	defer ticker.Stop()
+	for {
+		current, err := rpcpool.Read(ctx, c.pool, func(ctx context.Context, client *rpc.Client) (uint64, error) {
+			return client.GetSlot(ctx, rpc.CommitmentProcessed)
+		})
+		if err == nil && current >= slot {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for slot %d: %w", slot, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// Transfer sends lamports from the owner to to and waits until it is
+// confirmed.
+func (c *RPCClient) Transfer(ctx context.Context, to solana.PublicKey, lamports uint64) (solana.Signature, error) {
+	if c.user == nil {
+		return solana.Signature{}, fmt.Errorf("client has no private key")
+	}
+
+	ix := system.NewTransferInstruction(lamports, c.owner, to).Build()
+	tx, bh, err := c.newTransaction(ctx, []solana.Instruction{ix}, solana.PublicKey{})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	if err := c.guard(ctx, tx); err != nil {
+		return solana.Signature{}, err
+	}
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(c.owner) {
+			return &c.user.PrivateKey
+		}
+		return nil
+	}); err != nil {
+		return solana.Signature{}, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	sig, err := c.sendTransaction(ctx, tx, &bh.Context.Slot)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	if _, err := c.confirmTransaction(ctx, tx, bh.Value.LastValidBlockHeight); err != nil {
+		return sig, err
+	}
+	return sig, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned by Stat and Unpin for content the backend does not
+// hold.
+var ErrNotFound = errors.New("content not found")
+
+// Object describes stored content.
+type Object struct {
+	CID    string
+	Name   string
+	Size   int64
+	Pinned bool
+}
+
+// Storage hosts token images and metadata. The returned CID is what URI,
+// Pin, Unpin and Stat take.
+type Storage interface {
+	// Put stores the content of r as a file called name and pins it.
+	Put(ctx context.Context, name string, r io.Reader) (string, error)
+	Pin(ctx context.Context, cid string) error
+	Unpin(ctx context.Context, cid string) error
+	Stat(ctx context.Context, cid string) (*Object, error)
+	// URI is the link to put in token metadata.
+	URI(cid string) string
+}
+
+func PutBytes(ctx context.Context, s Storage, name string, data []byte) (string, error) {
+	return s.Put(ctx, name, bytes.NewReader(data))
+}
+
+func PutJSON(ctx context.Context, s Storage, name string, v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return PutBytes(ctx, s, name, data)
+}
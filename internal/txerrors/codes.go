@@ -0,0 +1,144 @@
+package txerrors
+
+import (
+	"fmt"
+
+	"pf-launcher/internal"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+type errorCode struct {
+	name      string
+	message   string
+	retryable bool
+	kind      error
+}
+
+// pumpFunErrors are the custom errors from the pump.fun IDL.
+var pumpFunErrors = map[uint32]errorCode{
+	6000: {"NotAuthorized", "The given account is not authorized to execute this instruction", false, ErrNotAuthorized},
+	6001: {"AlreadyInitialized", "The program is already initialized", false, ErrProgram},
+	6002: {"TooMuchSolRequired", "slippage: Too much SOL required to buy the given amount of tokens", true, ErrSlippageExceeded},
+	6003: {"TooLittleSolReceived", "slippage: Too little SOL received to sell the given amount of tokens", true, ErrSlippageExceeded},
+	6004: {"MintDoesNotMatchBondingCurve", "The mint does not match the bonding curve", false, ErrProgram},
+	6005: {"BondingCurveComplete", "The bonding curve has completed and liquidity migrated to raydium", false, ErrBondingCurveComplete},
+	6006: {"BondingCurveNotComplete", "The bonding curve has not completed", false, ErrProgram},
+	6007: {"NotInitialized", "The program is not initialized", false, ErrProgram},
+	6008: {"WithdrawTooFrequent", "Withdraw too frequent", true, ErrProgram},
+}
+
+// anchorErrors are the framework errors every Anchor program can return.
+var anchorErrors = map[uint32]errorCode{
+	100:  {"InstructionMissing", "8 byte instruction identifier not provided", false, ErrProgram},
+	101:  {"InstructionFallbackNotFound", "Fallback functions are not supported", false, ErrProgram},
+	102:  {"InstructionDidNotDeserialize", "The program could not deserialize the given instruction", false, ErrProgram},
+	2000: {"ConstraintMut", "A mut constraint was violated", false, ErrProgram},
+	2001: {"ConstraintHasOne", "A has one constraint was violated", false, ErrProgram},
+	2002: {"ConstraintSigner", "A signer constraint was violated", false, ErrNotAuthorized},
+	2003: {"ConstraintRaw", "A raw constraint was violated", false, ErrProgram},
+	2004: {"ConstraintOwner", "An owner constraint was violated", false, ErrProgram},
+	2005: {"ConstraintRentExempt", "A rent exemption constraint was violated", false, ErrProgram},
+	2006: {"ConstraintSeeds", "A seeds constraint was violated", false, ErrProgram},
+	3001: {"AccountDiscriminatorNotFound", "No discriminator was found on the account", false, ErrProgram},
+	3002: {"AccountDiscriminatorMismatch", "8 byte discriminator did not match what was expected", false, ErrProgram},
+	3003: {"AccountDidNotDeserialize", "Failed to deserialize the account", false, ErrProgram},
+	3007: {"AccountOwnedByWrongProgram", "The given account is owned by a different program than expected", false, ErrProgram},
+	3012: {"AccountNotInitialized", "The program expected this account to be already initialized", false, ErrProgram},
+}
+
+var systemErrors = map[uint32]errorCode{
+	0: {"AccountAlreadyInUse", "An account with the same address already exists", false, ErrAccountInUse},
+	1: {"ResultWithNegativeLamports", "Account does not have enough SOL to perform the operation", false, ErrInsufficientFunds},
+	2: {"InvalidProgramId", "Cannot assign account to this program id", false, ErrProgram},
+	3: {"InvalidAccountDataLength", "Cannot allocate account data of this length", false, ErrProgram},
+	4: {"MaxSeedLengthExceeded", "Length of requested seed is too long", false, ErrProgram},
+	5: {"AddressWithSeedMismatch", "Provided address does not match addressed derived from seed", false, ErrProgram},
+	6: {"NonceNoRecentBlockhashes", "Advancing stored nonce requires a populated RecentBlockhashes sysvar", true, ErrNonce},
+	7: {"NonceBlockhashNotExpired", "Stored nonce is still in recent_blockhashes", true, ErrNonce},
+	8: {"NonceUnexpectedBlockhashValue", "Specified nonce does not match stored nonce", false, ErrNonce},
+}
+
+var tokenErrors = map[uint32]errorCode{
+	0:  {"NotRentExempt", "Lamport balance below rent-exempt threshold", false, ErrInsufficientFunds},
+	1:  {"InsufficientFunds", "Insufficient funds", false, ErrInsufficientFunds},
+	2:  {"InvalidMint", "Invalid Mint", false, ErrProgram},
+	3:  {"MintMismatch", "Account not associated with this Mint", false, ErrProgram},
+	4:  {"OwnerMismatch", "Owner does not match", false, ErrNotAuthorized},
+	5:  {"FixedSupply", "Fixed supply", false, ErrProgram},
+	6:  {"AlreadyInUse", "Already in use", false, ErrAccountInUse},
+	7:  {"InvalidNumberOfProvidedSigners", "Invalid number of provided signers", false, ErrProgram},
+	8:  {"InvalidNumberOfRequiredSigners", "Invalid number of required signers", false, ErrProgram},
+	9:  {"UninitializedState", "State is uninitialized", false, ErrProgram},
+	10: {"NativeNotSupported", "Instruction does not support native tokens", false, ErrProgram},
+	11: {"NonNativeHasBalance", "Non-native account can only be closed if its balance is zero", false, ErrProgram},
+	12: {"InvalidInstruction", "Invalid instruction", false, ErrProgram},
+	13: {"InvalidState", "State is invalid for requested operation", false, ErrProgram},
+	14: {"Overflow", "Operation overflowed", false, ErrProgram},
+	15: {"AuthorityTypeNotSupported", "Account does not support specified authority type", false, ErrProgram},
+	16: {"MintCannotFreeze", "This token mint cannot freeze accounts", false, ErrProgram},
+	17: {"AccountFrozen", "Account is frozen", false, ErrProgram},
+	18: {"MintDecimalsMismatch", "The provided decimals value different from the Mint decimals", false, ErrProgram},
+	19: {"NonNativeNotSupported", "Instruction does not support non-native tokens", false, ErrProgram},
+}
+
+func lookupCustom(programID solana.PublicKey, code uint32) (string, string, bool, error) {
+	var table map[uint32]errorCode
+	switch {
+	case programID.Equals(solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)):
+		table = pumpFunErrors
+		if _, ok := table[code]; !ok {
+			table = anchorErrors
+		}
+	case programID.Equals(solana.SystemProgramID):
+		table = systemErrors
+	case programID.Equals(solana.TokenProgramID):
+		table = tokenErrors
+	}
+
+	if known, ok := table[code]; ok {
+		return known.name, known.message, known.retryable, known.kind
+	}
+	return "Custom", fmt.Sprintf("custom program error 0x%x", code), false, ErrProgram
+}
+
+// builtin maps the runtime's InstructionError variants, which is how the
+// ComputeBudget program and native programs without custom codes fail.
+func builtin(name string) (string, bool, error) {
+	switch name {
+	case "ComputationalBudgetExceeded", "ProgramFailedToComplete":
+		return "The instruction ran out of compute units, raise the compute unit limit", true, ErrComputeBudget
+	case "InvalidInstructionData":
+		return "The instruction data is invalid", false, ErrProgram
+	case "InsufficientFunds":
+		return "An account does not have enough lamports", false, ErrInsufficientFunds
+	case "AccountAlreadyInitialized":
+		return "An account is already initialized", false, ErrAccountInUse
+	case "MissingRequiredSignature":
+		return "A required signature is missing", false, ErrNotAuthorized
+	default:
+		return name, false, ErrProgram
+	}
+}
+
+// transactionLevel maps failures that happen before any instruction runs.
+func transactionLevel(name string) (string, bool, error) {
+	switch name {
+	case "BlockhashNotFound":
+		return "The blockhash has expired or is not yet known to the node", true, ErrBlockhashNotFound
+	case "InsufficientFundsForFee":
+		return "The fee payer cannot cover the transaction fee", false, ErrInsufficientFunds
+	case "InsufficientFundsForRent":
+		return "An account would be left below the rent-exempt minimum", false, ErrInsufficientFunds
+	case "AccountInUse":
+		return "An account is locked by another transaction", true, ErrProgram
+	case "AlreadyProcessed":
+		return "The transaction has already been processed", false, ErrAlreadyProcessed
+	case "DuplicateInstruction":
+		return "A compute budget instruction appears more than once", false, ErrComputeBudget
+	case "WouldExceedMaxBlockCostLimit", "WouldExceedMaxAccountCostLimit", "WouldExceedAccountDataBlockLimit":
+		return "The block is full", true, ErrProgram
+	default:
+		return name, false, ErrProgram
+	}
+}
@@ -0,0 +1,136 @@
+package txerrors
+
+import (
+	"errors"
+	"testing"
+
+	"pf-launcher/internal"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// testTx calls pump.fun, System and Token in instructions 0, 1 and 2.
+func testTx() *solana.Transaction {
+	return &solana.Transaction{Message: solana.Message{
+		AccountKeys: solana.PublicKeySlice{
+			solana.NewWallet().PublicKey(),
+			solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM),
+			solana.SystemProgramID,
+			solana.TokenProgramID,
+		},
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1}, {ProgramIDIndex: 2}, {ProgramIDIndex: 3}},
+	}}
+}
+
+// instructionError is metaErr as JSON decodes {"InstructionError":[i,inner]}.
+func instructionError(index int, inner interface{}) map[string]interface{} {
+	return map[string]interface{}{"InstructionError": []interface{}{float64(index), inner}}
+}
+
+func custom(code uint32) map[string]interface{} {
+	return map[string]interface{}{"Custom": float64(code)}
+}
+
+func TestFromMetaErr(t *testing.T) {
+	tests := []struct {
+		name      string
+		metaErr   interface{}
+		wantName  string
+		wantKind  error
+		retryable bool
+		index     int
+		program   string
+	}{
+		{"blockhash not found", "BlockhashNotFound", "BlockhashNotFound", ErrBlockhashNotFound, true, -1, ""},
+		{"already processed", "AlreadyProcessed", "AlreadyProcessed", ErrAlreadyProcessed, false, -1, ""},
+		{"account in use", "AccountInUse", "AccountInUse", ErrProgram, true, -1, ""},
+		{"fee", "InsufficientFundsForFee", "InsufficientFundsForFee", ErrInsufficientFunds, false, -1, ""},
+		{"rent with detail", map[string]interface{}{"InsufficientFundsForRent": map[string]interface{}{"account_index": float64(2)}}, "InsufficientFundsForRent", ErrInsufficientFunds, false, -1, ""},
+		{"unknown transaction error", "SomethingNew", "SomethingNew", ErrProgram, false, -1, ""},
+		{"pump.fun not authorized", instructionError(0, custom(6000)), "NotAuthorized", ErrNotAuthorized, false, 0, "pump.fun"},
+		{"pump.fun slippage", instructionError(0, custom(6002)), "TooMuchSolRequired", ErrSlippageExceeded, true, 0, "pump.fun"},
+		{"pump.fun sell slippage", instructionError(0, custom(6003)), "TooLittleSolReceived", ErrSlippageExceeded, true, 0, "pump.fun"},
+		{"pump.fun curve complete", instructionError(0, custom(6005)), "BondingCurveComplete", ErrBondingCurveComplete, false, 0, "pump.fun"},
+		{"pump.fun withdraw", instructionError(0, custom(6008)), "WithdrawTooFrequent", ErrProgram, true, 0, "pump.fun"},
+		{"anchor constraint", instructionError(0, custom(2006)), "ConstraintSeeds", ErrProgram, false, 0, "pump.fun"},
+		{"anchor signer", instructionError(0, custom(2002)), "ConstraintSigner", ErrNotAuthorized, false, 0, "pump.fun"},
+		{"unknown custom", instructionError(0, custom(9999)), "Custom", ErrProgram, false, 0, "pump.fun"},
+		{"system account in use", instructionError(1, custom(0)), "AccountAlreadyInUse", ErrAccountInUse, false, 1, "System"},
+		{"system nonce", instructionError(1, custom(7)), "NonceBlockhashNotExpired", ErrNonce, true, 1, "System"},
+		{"token funds", instructionError(2, custom(1)), "InsufficientFunds", ErrInsufficientFunds, false, 2, "Token"},
+		{"compute budget", instructionError(0, "ComputationalBudgetExceeded"), "ComputationalBudgetExceeded", ErrComputeBudget, true, 0, "pump.fun"},
+		{"missing signature", instructionError(1, "MissingRequiredSignature"), "MissingRequiredSignature", ErrNotAuthorized, false, 1, "System"},
+		{"custom code of an unknown instruction", instructionError(5, custom(0)), "Custom", ErrProgram, false, 5, "unknown program"},
+		{"undecoded", float64(7), "Unknown", ErrProgram, true, -1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txErr := FromMetaErr(tt.metaErr, testTx(), nil)
+			if txErr == nil {
+				t.Fatal("FromMetaErr = nil")
+			}
+			if txErr.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", txErr.Name, tt.wantName)
+			}
+			if !errors.Is(txErr, tt.wantKind) {
+				t.Errorf("kind = %v, want %v", txErr.kind, tt.wantKind)
+			}
+			if txErr.Retryable != tt.retryable {
+				t.Errorf("Retryable = %v, want %v", txErr.Retryable, tt.retryable)
+			}
+			if txErr.InstructionIndex != tt.index {
+				t.Errorf("InstructionIndex = %d, want %d", txErr.InstructionIndex, tt.index)
+			}
+			if tt.program != "" && txErr.ProgramName != tt.program {
+				t.Errorf("ProgramName = %q, want %q", txErr.ProgramName, tt.program)
+			}
+		})
+	}
+}
+
+func TestFromMetaErrNil(t *testing.T) {
+	if txErr := FromMetaErr(nil, testTx(), nil); txErr != nil {
+		t.Errorf("FromMetaErr(nil) = %v", txErr)
+	}
+}
+
+func TestFromRPCError(t *testing.T) {
+	err := &jsonrpc.RPCError{
+		Code:    -32002,
+		Message: "Transaction simulation failed: Error processing Instruction 0: custom program error: 0x1772",
+		Data: map[string]interface{}{
+			"err":  instructionError(0, custom(6002)),
+			"logs": []interface{}{"Program log: slippage"},
+		},
+	}
+	txErr := FromRPCError(err, testTx())
+	if txErr == nil {
+		t.Fatal("FromRPCError = nil")
+	}
+	if !errors.Is(txErr, ErrSlippageExceeded) || txErr.Code == nil || *txErr.Code != 6002 {
+		t.Errorf("FromRPCError = %v, want TooMuchSolRequired", txErr)
+	}
+	if len(txErr.Logs) != 1 {
+		t.Errorf("Logs = %v, want the simulation log", txErr.Logs)
+	}
+
+	if txErr := FromRPCError(&jsonrpc.RPCError{Code: -32005, Message: "node is behind"}, testTx()); txErr != nil {
+		t.Errorf("FromRPCError without data = %v", txErr)
+	}
+	if txErr := FromRPCError(errors.New("eof"), testTx()); txErr != nil {
+		t.Errorf("FromRPCError of a plain error = %v", txErr)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if IsRetryable(FromMetaErr("AlreadyProcessed", nil, nil)) {
+		t.Error("AlreadyProcessed is retryable")
+	}
+	if !IsRetryable(FromMetaErr("BlockhashNotFound", nil, nil)) {
+		t.Error("BlockhashNotFound is not retryable")
+	}
+	if !IsRetryable(errors.New("connection reset")) {
+		t.Error("an undecoded error is not retryable")
+	}
+}
@@ -0,0 +1,217 @@
+package txerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"pf-launcher/internal"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+var (
+	ErrSlippageExceeded     = errors.New("slippage exceeded")
+	ErrBondingCurveComplete = errors.New("bonding curve complete")
+	ErrNotAuthorized        = errors.New("not authorized")
+	ErrInsufficientFunds    = errors.New("insufficient funds")
+	ErrBlockhashNotFound    = errors.New("blockhash not found")
+	ErrAccountInUse         = errors.New("account already in use")
+	ErrNonce                = errors.New("durable nonce error")
+	ErrComputeBudget        = errors.New("compute budget exceeded")
+	ErrAlreadyProcessed     = errors.New("already processed")
+	ErrProgram              = errors.New("program error")
+)
+
+// TransactionError is a decoded transaction failure.
+type TransactionError struct {
+	// InstructionIndex is -1 when the failure is not tied to an instruction.
+	InstructionIndex int
+	Program          solana.PublicKey
+	ProgramName      string
+	// Code is the custom program error code, when there is one.
+	Code    *uint32
+	Name    string
+	Message string
+	// Retryable means a rebuilt transaction, with a fresh blockhash, quote
+	// or compute budget, may succeed. Resending the same signed transaction
+	// never helps.
+	Retryable bool
+	Logs      []string
+
+	kind error
+}
+
+func (e *TransactionError) Error() string {
+	where := "transaction"
+	if e.InstructionIndex >= 0 {
+		where = fmt.Sprintf("instruction %d (%s)", e.InstructionIndex, e.ProgramName)
+	}
+	retry := "not retryable"
+	if e.Retryable {
+		retry = "retryable after rebuilding"
+	}
+	if e.Code != nil {
+		return fmt.Sprintf("%s failed with %s (%d): %s [%s]", where, e.Name, *e.Code, e.Message, retry)
+	}
+	return fmt.Sprintf("%s failed with %s: %s [%s]", where, e.Name, e.Message, retry)
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.kind
+}
+
+// IsRetryable reports whether err is a decoded failure that may succeed if the
+// transaction is rebuilt, re-signed and sent again. Errors that were not
+// decoded are treated as retryable.
+func IsRetryable(err error) bool {
+	var txErr *TransactionError
+	if errors.As(err, &txErr) {
+		return txErr.Retryable
+	}
+	return true
+}
+
+// FromRPCError decodes the simulation failure carried by a preflight
+// jsonrpc.RPCError. It returns nil when err carries no transaction error.
+func FromRPCError(err error, tx *solana.Transaction) *TransactionError {
+	var rpcErr *jsonrpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		return nil
+	}
+
+	data, ok := rpcErr.Data.(map[string]interface{})
+	if !ok || data["err"] == nil {
+		return nil
+	}
+
+	var logs []string
+	if rawLogs, ok := data["logs"].([]interface{}); ok {
+		for _, line := range rawLogs {
+			if s, ok := line.(string); ok {
+				logs = append(logs, s)
+			}
+		}
+	}
+
+	return FromMetaErr(data["err"], tx, logs)
+}
+
+// FromMetaErr decodes a transaction error as found in simulation results and
+// in the meta of a confirmed transaction. It returns nil when metaErr is nil.
+func FromMetaErr(metaErr interface{}, tx *solana.Transaction, logs []string) *TransactionError {
+	if metaErr == nil {
+		return nil
+	}
+
+	txErr := &TransactionError{InstructionIndex: -1, Logs: logs}
+	switch value := metaErr.(type) {
+	case string:
+		txErr.Name = value
+		txErr.Message, txErr.Retryable, txErr.kind = transactionLevel(value)
+		return txErr
+	case map[string]interface{}:
+		for name, detail := range value {
+			txErr.Name = name
+			if name == "InstructionError" {
+				decodeInstructionError(txErr, detail, tx)
+				return txErr
+			}
+			txErr.Message, txErr.Retryable, txErr.kind = transactionLevel(name)
+			if detail != nil {
+				txErr.Message = fmt.Sprintf("%s %v", txErr.Message, detail)
+			}
+			return txErr
+		}
+	}
+
+	txErr.Name = "Unknown"
+	txErr.Message = fmt.Sprintf("%v", metaErr)
+	txErr.Retryable = true
+	txErr.kind = ErrProgram
+	return txErr
+}
+
+func decodeInstructionError(txErr *TransactionError, detail interface{}, tx *solana.Transaction) {
+	pair, ok := detail.([]interface{})
+	if !ok || len(pair) != 2 {
+		txErr.Message = fmt.Sprintf("%v", detail)
+		txErr.kind = ErrProgram
+		return
+	}
+
+	// The System program's id is the zero key, so whether the program was
+	// found is tracked apart from txErr.Program.
+	resolved := false
+	txErr.ProgramName = "unknown program"
+	if index, ok := toInt(pair[0]); ok {
+		txErr.InstructionIndex = index
+		if tx != nil && index < len(tx.Message.Instructions) {
+			if programID, err := tx.Message.Program(tx.Message.Instructions[index].ProgramIDIndex); err == nil {
+				txErr.Program, txErr.ProgramName, resolved = programID, programName(programID), true
+			}
+		}
+	}
+
+	switch inner := pair[1].(type) {
+	case string:
+		txErr.Name = inner
+		txErr.Message, txErr.Retryable, txErr.kind = builtin(inner)
+	case map[string]interface{}:
+		custom, ok := toInt(inner["Custom"])
+		if !ok {
+			for name, value := range inner {
+				txErr.Name = name
+				txErr.Message = fmt.Sprintf("%v", value)
+			}
+			txErr.kind = ErrProgram
+			return
+		}
+		code := uint32(custom)
+		txErr.Code = &code
+		if !resolved {
+			txErr.Name, txErr.Message, txErr.kind = "Custom", fmt.Sprintf("custom program error 0x%x", code), ErrProgram
+			return
+		}
+		txErr.Name, txErr.Message, txErr.Retryable, txErr.kind = lookupCustom(txErr.Program, code)
+	default:
+		txErr.Message = fmt.Sprintf("%v", inner)
+		txErr.kind = ErrProgram
+	}
+}
+
+func programName(programID solana.PublicKey) string {
+	switch {
+	case programID.Equals(solana.MustPublicKeyFromBase58(internal.PUMP_FUN_PROGRAM)):
+		return "pump.fun"
+	case programID.Equals(solana.SystemProgramID):
+		return "System"
+	case programID.Equals(solana.TokenProgramID):
+		return "Token"
+	case programID.Equals(solana.ComputeBudget):
+		return "ComputeBudget"
+	case programID.Equals(solana.SPLAssociatedTokenAccountProgramID):
+		return "AssociatedTokenAccount"
+	default:
+		return programID.String()
+	}
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := strconv.Atoi(v.String())
+		return n, err == nil
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	}
+	return 0, false
+}
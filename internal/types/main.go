@@ -12,6 +12,11 @@ type BuyData struct {
 	MaxSolCost uint64
 }
 
+type SellData struct {
+	Amount       uint64
+	MinSolOutput uint64
+}
+
 type CreateData struct {
 	Name    string           `bson:"name"`
 	Symbol  string           `bson:"symbol"`
@@ -31,6 +36,35 @@ type Metadata struct {
 	Website     string `json:"website"`
 }
 
+type CreateEvent struct {
+	Name         string           `borsh:"name"`
+	Symbol       string           `borsh:"symbol"`
+	Uri          string           `borsh:"uri"`
+	Mint         solana.PublicKey `borsh:"mint"`
+	BondingCurve solana.PublicKey `borsh:"bonding_curve"`
+	User         solana.PublicKey `borsh:"user"`
+}
+
+type TradeEvent struct {
+	Mint                 solana.PublicKey `borsh:"mint"`
+	SolAmount            uint64           `borsh:"sol_amount"`
+	TokenAmount          uint64           `borsh:"token_amount"`
+	IsBuy                bool             `borsh:"is_buy"`
+	User                 solana.PublicKey `borsh:"user"`
+	Timestamp            int64            `borsh:"timestamp"`
+	VirtualSolReserves   uint64           `borsh:"virtual_sol_reserves"`
+	VirtualTokenReserves uint64           `borsh:"virtual_token_reserves"`
+	RealSolReserves      uint64           `borsh:"real_sol_reserves"`
+	RealTokenReserves    uint64           `borsh:"real_token_reserves"`
+}
+
+type CompleteEvent struct {
+	User         solana.PublicKey `borsh:"user"`
+	Mint         solana.PublicKey `borsh:"mint"`
+	BondingCurve solana.PublicKey `borsh:"bonding_curve"`
+	Timestamp    int64            `borsh:"timestamp"`
+}
+
 type GlobalAccount struct {
 	Discriminator               uint64           `borsh:"discriminator"`
 	Initialized                 bool             `borsh:"initialized"`
@@ -85,3 +119,106 @@ func (g *GlobalAccount) GetInitialBuyPrice(solAmount uint64) (uint64, error) {
 	}
 	return g.InitialRealTokenReserves, nil
 }
+
+// GetInitialBuyCost returns the lamports, protocol fee included, that buying
+// tokenAmount tokens costs on a fresh bonding curve.
+func (g *GlobalAccount) GetInitialBuyCost(tokenAmount uint64) (uint64, error) {
+	if tokenAmount == 0 {
+		return 0, nil
+	}
+	if tokenAmount >= g.InitialVirtualTokenReserves {
+		return 0, fmt.Errorf("token amount exceeds reserves")
+	}
+
+	vSol := new(big.Int).SetUint64(g.InitialVirtualSolReserves)
+	vToken := new(big.Int).SetUint64(g.InitialVirtualTokenReserves)
+	amount := new(big.Int).SetUint64(tokenAmount)
+
+	// sol = vSol * amount / (vToken - amount) + 1
+	sol := new(big.Int).Mul(vSol, amount)
+	sol.Div(sol, new(big.Int).Sub(vToken, amount))
+	sol.Add(sol, big.NewInt(1))
+
+	fee := new(big.Int).Mul(sol, new(big.Int).SetUint64(g.FeeBasisPoints))
+	fee.Div(fee, big.NewInt(10000))
+	sol.Add(sol, fee)
+
+	if !sol.IsUint64() {
+		return 0, fmt.Errorf("sol amount overflow")
+	}
+	return sol.Uint64(), nil
+}
+
+type BondingCurveAccount struct {
+	Discriminator        uint64           `borsh:"discriminator"`
+	VirtualTokenReserves uint64           `borsh:"virtual_token_reserves"`
+	VirtualSolReserves   uint64           `borsh:"virtual_sol_reserves"`
+	RealTokenReserves    uint64           `borsh:"real_token_reserves"`
+	RealSolReserves      uint64           `borsh:"real_sol_reserves"`
+	TokenTotalSupply     uint64           `borsh:"token_total_supply"`
+	Complete             bool             `borsh:"complete"`
+	Creator              solana.PublicKey `borsh:"creator"`
+}
+
+// GetBuyPrice returns the number of tokens received for solAmount lamports,
+// after the protocol fee has been taken out.
+func (b *BondingCurveAccount) GetBuyPrice(solAmount, feeBasisPoints uint64) (uint64, error) {
+	if b.Complete {
+		return 0, fmt.Errorf("bonding curve is complete")
+	}
+	if solAmount == 0 {
+		return 0, nil
+	}
+
+	vSol := new(big.Int).SetUint64(b.VirtualSolReserves)
+	vToken := new(big.Int).SetUint64(b.VirtualTokenReserves)
+
+	// Remove the fee: sol * 10000 / (10000 + fee)
+	amount := new(big.Int).Mul(new(big.Int).SetUint64(solAmount), big.NewInt(10000))
+	amount.Div(amount, new(big.Int).SetUint64(10000+feeBasisPoints))
+
+	// tokens = vToken * amount / (vSol + amount)
+	tokens := new(big.Int).Mul(vToken, amount)
+	tokens.Div(tokens, new(big.Int).Add(vSol, amount))
+
+	if !tokens.IsUint64() {
+		return 0, fmt.Errorf("token amount overflow")
+	}
+
+	result := tokens.Uint64()
+	if result < b.RealTokenReserves {
+		return result, nil
+	}
+	return b.RealTokenReserves, nil
+}
+
+// GetSellPrice returns the lamports received for selling tokenAmount tokens,
+// after the protocol fee has been taken out.
+func (b *BondingCurveAccount) GetSellPrice(tokenAmount, feeBasisPoints uint64) (uint64, error) {
+	if b.Complete {
+		return 0, fmt.Errorf("bonding curve is complete")
+	}
+	if tokenAmount == 0 {
+		return 0, nil
+	}
+
+	vSol := new(big.Int).SetUint64(b.VirtualSolReserves)
+	vToken := new(big.Int).SetUint64(b.VirtualTokenReserves)
+	amount := new(big.Int).SetUint64(tokenAmount)
+
+	// sol = vSol * amount / (vToken + amount)
+	sol := new(big.Int).Mul(vSol, amount)
+	sol.Div(sol, new(big.Int).Add(vToken, amount))
+
+	fee := new(big.Int).Mul(sol, new(big.Int).SetUint64(feeBasisPoints))
+	fee.Div(fee, big.NewInt(10000))
+	sol.Sub(sol, fee)
+
+	if sol.Sign() < 0 {
+		return 0, fmt.Errorf("negative sol amount calculated")
+	}
+	if !sol.IsUint64() {
+		return 0, fmt.Errorf("sol amount overflow")
+	}
+	return sol.Uint64(), nil
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"pf-launcher/internal"
+)
+
+// FieldError is a problem with one metadata field.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// ValidationError lists every invalid field, so all of them can be fixed at
+// once.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	reasons := make([]string, len(e))
+	for i, field := range e {
+		reasons[i] = field.Error()
+	}
+	return "invalid metadata: " + strings.Join(reasons, "; ")
+}
+
+func (e ValidationError) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+var (
+	twitterHandle  = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+	telegramHandle = regexp.MustCompile(`^[A-Za-z0-9_]{5,32}$`)
+)
+
+// Validate normalizes the metadata in place and checks it against the
+// on-chain limits. Name and symbol are trimmed and social links rewritten to
+// canonical https URLs; empty links are left empty.
+func (m *Metadata) Validate() error {
+	var errs ValidationError
+	m.Name = strings.TrimSpace(m.Name)
+	m.Symbol = strings.TrimSpace(m.Symbol)
+
+	errs = checkText(errs, "name", m.Name, internal.MAX_NAME_LENGTH)
+	errs = checkText(errs, "symbol", m.Symbol, internal.MAX_SYMBOL_LENGTH)
+	if strings.IndexFunc(m.Symbol, unicode.IsSpace) >= 0 {
+		errs = append(errs, FieldError{"symbol", "must not contain spaces"})
+	}
+	if !utf8.ValidString(m.Description) {
+		errs = append(errs, FieldError{"description", "is not valid UTF-8"})
+	}
+
+	var err error
+	if m.Twitter, err = normalizeTwitter(m.Twitter); err != nil {
+		errs = append(errs, FieldError{"twitter", err.Error()})
+	}
+	if m.Telegram, err = normalizeTelegram(m.Telegram); err != nil {
+		errs = append(errs, FieldError{"telegram", err.Error()})
+	}
+	if m.Website, err = normalizeWebsite(m.Website); err != nil {
+		errs = append(errs, FieldError{"website", err.Error()})
+	}
+	return errs.err()
+}
+
+// Validate checks the create instruction data against the on-chain limits.
+func (d CreateData) Validate() error {
+	var errs ValidationError
+	errs = checkText(errs, "name", d.Name, internal.MAX_NAME_LENGTH)
+	errs = checkText(errs, "symbol", d.Symbol, internal.MAX_SYMBOL_LENGTH)
+	errs = checkText(errs, "uri", d.Uri, internal.MAX_URI_LENGTH)
+	return errs.err()
+}
+
+// checkText checks a required field. Limits are in bytes, as stored
+// on-chain, so a 10 character name of emoji is already too long.
+func checkText(errs ValidationError, field, value string, limit int) ValidationError {
+	switch {
+	case value == "":
+		return append(errs, FieldError{field, "is required"})
+	case !utf8.ValidString(value):
+		return append(errs, FieldError{field, "is not valid UTF-8"})
+	case strings.IndexFunc(value, unicode.IsControl) >= 0:
+		return append(errs, FieldError{field, "contains control characters"})
+	case len(value) > limit:
+		return append(errs, FieldError{field, fmt.Sprintf("is %d bytes (%d characters), the limit is %d bytes",
+			len(value), utf8.RuneCountInString(value), limit)})
+	}
+	return errs
+}
+
+// parseLink parses a link that may be missing its scheme.
+func parseLink(link string) (*url.URL, error) {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("is not a URL")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("must be an http or https URL")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("must not contain credentials")
+	}
+	u.Scheme = "https"
+	u.Host = strings.ToLower(u.Host)
+	return u, nil
+}
+
+// normalizeTwitter accepts a handle, @handle or a twitter.com or x.com link
+// and returns an https://x.com link.
+func normalizeTwitter(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return "", nil
+	}
+	if handle := strings.TrimPrefix(link, "@"); twitterHandle.MatchString(handle) {
+		return "https://x.com/" + handle, nil
+	}
+
+	u, err := parseLink(link)
+	if err != nil {
+		return "", err
+	}
+	switch strings.TrimPrefix(u.Host, "www.") {
+	case "x.com", "twitter.com", "mobile.twitter.com", "mobile.x.com":
+	default:
+		return "", fmt.Errorf("must be an x.com or twitter.com link or a handle, got %s", u.Host)
+	}
+	path := strings.Trim(u.Path, "/")
+	handle, _, _ := strings.Cut(path, "/")
+	if handle != "i" && !twitterHandle.MatchString(handle) {
+		return "", fmt.Errorf("%q is not a valid handle", handle)
+	}
+	return "https://x.com/" + path, nil
+}
+
+// normalizeTelegram accepts a name, @name or a t.me or telegram.me link and
+// returns an https://t.me link. Invite links are kept as they are.
+func normalizeTelegram(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return "", nil
+	}
+	if name := strings.TrimPrefix(link, "@"); telegramHandle.MatchString(name) {
+		return "https://t.me/" + name, nil
+	}
+
+	u, err := parseLink(link)
+	if err != nil {
+		return "", err
+	}
+	switch strings.TrimPrefix(u.Host, "www.") {
+	case "t.me", "telegram.me", "telegram.dog":
+	default:
+		return "", fmt.Errorf("must be a t.me link or a name, got %s", u.Host)
+	}
+	path := strings.Trim(u.Path, "/")
+	name, _, _ := strings.Cut(path, "/")
+	if !strings.HasPrefix(name, "+") && name != "joinchat" && !telegramHandle.MatchString(name) {
+		return "", fmt.Errorf("%q is not a valid name", name)
+	}
+	return "https://t.me/" + path, nil
+}
+
+// normalizeWebsite adds a missing https scheme and checks there is a host.
+func normalizeWebsite(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return "", nil
+	}
+	if strings.ContainsAny(link, " \t\n") {
+		return "", fmt.Errorf("must not contain spaces")
+	}
+	scheme := "https"
+	if strings.HasPrefix(strings.ToLower(link), "http://") {
+		scheme = "http"
+	}
+
+	u, err := parseLink(link)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(u.Hostname(), ".") {
+		return "", fmt.Errorf("%q is not a domain", u.Host)
+	}
+	u.Scheme = scheme
+	u.Fragment = ""
+	return u.String(), nil
+}